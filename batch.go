@@ -1,129 +1,404 @@
 package grog
 
 import (
+	"fmt"
 	"image/color"
 	"math"
+	"runtime"
 
 	"github.com/db47h/grog/gl"
+	"golang.org/x/xerrors"
 )
 
 const (
-	floatsPerVertex = 8
-	floatsPerQuad   = floatsPerVertex * 4
-	indicesPerQuad  = 6
-	batchSize       = 5000
+	indicesPerQuad    = 6
+	batchSize         = 5000 // default max quads per batch
+	dispatchThreshold = 500  // default threshold for dispatching to workers
+	maxTextureUnits   = 16
+	maxQuads16        = 16383 // max quads per batch with GL_UNSIGNED_SHORT indices
 )
 
-func loadShaders() (gl.Program, error) {
-	var (
-		vertex, frag gl.Shader
-		err          error
-	)
-	vertex, err = gl.NewShader(gl.GL_VERTEX_SHADER, vertexShader)
-	if err != nil {
-		return 0, err
+// An indexFormat is the element index type used by a batch.
+//
+type indexFormat struct {
+	typ uint32   // GL_UNSIGNED_INT or GL_UNSIGNED_SHORT
+	u16 []uint16 // conversion buffer for GL_UNSIGNED_SHORT indices
+}
+
+func newIndexFormat() indexFormat {
+	return indexFormat{typ: indexType()}
+}
+
+// maxQuads returns the maximum number of quads in a batch for a requested
+// capacity of n quads.
+//
+func (f *indexFormat) maxQuads(n int) int {
+	if f.typ == gl.GL_UNSIGNED_SHORT && n > maxQuads16 {
+		return maxQuads16
 	}
-	defer vertex.Delete()
-	frag, err = gl.NewShader(gl.GL_FRAGMENT_SHADER, fragmentShader)
-	if err != nil {
-		return 0, err
+	return n
+}
+
+// size returns the size of an index in bytes.
+//
+func (f *indexFormat) size() int {
+	if f.typ == gl.GL_UNSIGNED_SHORT {
+		return 2
 	}
-	defer frag.Delete()
+	return 4
+}
 
-	program, err := gl.NewProgram(vertex, frag)
-	if err != nil {
-		return 0, err
+// bufferData uploads indices to the current element array buffer, and returns
+// the number of bytes uploaded.
+//
+func (f *indexFormat) bufferData(indices []uint32, usage uint32) int {
+	if f.typ == gl.GL_UNSIGNED_SHORT {
+		f.u16 = f.u16[:0]
+		for _, i := range indices {
+			f.u16 = append(f.u16, uint16(i))
+		}
+		gl.BufferData(gl.GL_ELEMENT_ARRAY_BUFFER, len(f.u16)*2, gl.Ptr(&f.u16[0]), usage)
+		return len(f.u16) * 2
 	}
+	gl.BufferData(gl.GL_ELEMENT_ARRAY_BUFFER, len(indices)*4, gl.Ptr(&indices[0]), usage)
+	return len(indices) * 4
+}
 
-	return program, nil
+// A textureSet keeps track of the textures used in a batch. Each texture is
+// bound to its own texture unit when the batch is drawn.
+//
+type textureSet struct {
+	ds    []Drawable
+	ids   []uint32
+	last  int
+	limit int // number of texture units available to the current material
 }
 
-func batchInit(vbo, ebo uint32) {
-	indices := make([]uint32, batchSize*indicesPerQuad)
-	for i, j := 0, uint32(0); i < len(indices); i, j = i+indicesPerQuad, j+4 {
-		indices[i+0] = j + 0
-		indices[i+1] = j + 1
-		indices[i+2] = j + 2
-		indices[i+3] = j + 2
-		indices[i+4] = j + 1
-		indices[i+5] = j + 3
+func newTextureSet(units int) textureSet {
+	return textureSet{ds: make([]Drawable, 0, units), ids: make([]uint32, 0, units), limit: units}
+}
+
+// index returns the texture unit for d, assigning a new one if necessary. It
+// returns -1 if all texture units are in use.
+//
+func (s *textureSet) index(d Drawable) int {
+	id := d.NativeID()
+	if s.last < len(s.ids) && s.ids[s.last] == id {
+		return s.last
+	}
+	for i, tid := range s.ids {
+		if tid == id {
+			s.last = i
+			return i
+		}
 	}
+	if len(s.ids) >= s.limit {
+		return -1
+	}
+	s.ds = append(s.ds, d)
+	s.ids = append(s.ids, id)
+	s.last = len(s.ids) - 1
+	return s.last
+}
 
-	gl.BindBuffer(gl.GL_ARRAY_BUFFER, vbo)
-	gl.BufferData(gl.GL_ARRAY_BUFFER, batchSize*floatsPerQuad*4, nil, gl.GL_DYNAMIC_DRAW)
+// bind binds all textures to their texture unit.
+//
+func (s *textureSet) bind() {
+	for i, d := range s.ds {
+		gl.ActiveTexture(gl.GL_TEXTURE0 + uint32(i))
+		d.Bind()
+	}
+	if len(s.ds) > 1 {
+		gl.ActiveTexture(gl.GL_TEXTURE0)
+	}
+}
 
-	gl.BindBuffer(gl.GL_ELEMENT_ARRAY_BUFFER, ebo)
-	gl.BufferData(gl.GL_ELEMENT_ARRAY_BUFFER, len(indices)*4, gl.Ptr(&indices[0]), gl.GL_STATIC_DRAW)
+func (s *textureSet) reset() {
+	for i := range s.ds {
+		s.ds[i] = nil
+	}
+	s.ds = s.ds[:0]
+	s.ids = s.ids[:0]
+	s.last = 0
+}
+
+// appendQuadIndices appends the indices of n quads, starting at quad slot
+// first, to dst.
+//
+func appendQuadIndices(dst []uint32, first, n int) []uint32 {
+	for j := uint32(first * 4); n > 0; n, j = n-1, j+4 {
+		dst = append(dst, j+0, j+1, j+2, j+2, j+1, j+3)
+	}
+	return dst
+}
+
+// checkMesh panics if indices are not valid for a mesh with n vertices.
+//
+func checkMesh(n int, indices []uint16) {
+	if len(indices)%3 != 0 {
+		panic("number of mesh indices must be a multiple of 3")
+	}
+	for _, i := range indices {
+		if int(i) >= n {
+			panic(fmt.Sprintf("mesh index %d out of range [0, %d)", i, n))
+		}
+	}
+}
+
+// expandMesh returns the vertices of an indexed mesh as a triangle list.
+//
+func expandMesh(v []Vertex, indices []uint16) []Vertex {
+	t := make([]Vertex, len(indices))
+	for i, j := range indices {
+		t[i] = v[j]
+	}
+	return t
+}
 
+// meshSlots returns the number of quad slots used by a mesh with n vertices.
+//
+func meshSlots(n int) int {
+	return (n + 3) / 4
+}
+
+// batchInit enables the GL state common to all batches.
+//
+func batchInit() {
 	gl.Enable(gl.GL_SCISSOR_TEST)
 	gl.Enable(gl.GL_BLEND)
-	gl.BlendFunc(gl.GL_ONE, gl.GL_ONE_MINUS_SRC_ALPHA)
 }
 
-func batchBegin(vbo, ebo uint32, program gl.Program, pos, color uint32, texture int32) {
-	program.Use()
-	gl.ActiveTexture(gl.GL_TEXTURE0)
-	gl.Uniform1i(texture, 0)
-	gl.BindBuffer(gl.GL_ARRAY_BUFFER, vbo)
-	gl.BindBuffer(gl.GL_ELEMENT_ARRAY_BUFFER, ebo)
-	gl.EnableVertexAttribArray(pos)
-	gl.VertexAttribOffset(pos, 4, gl.GL_FLOAT, gl.GL_FALSE, floatsPerVertex*4, 0)
-	gl.EnableVertexAttribArray(color)
-	gl.VertexAttribOffset(color, 4, gl.GL_FLOAT, gl.GL_FALSE, floatsPerVertex*4, 4*4)
+// newWhiteTexture returns the 1x1 white texture used to draw untextured
+// triangles.
+//
+func newWhiteTexture() *Texture {
+	pix := [4]uint8{0xff, 0xff, 0xff, 0xff}
+	return newTexture(1, 1, gl.GL_RGBA, &pix[0], Filter(Nearest, Nearest))
 }
 
-func NewBatch(concurrent bool) (BatchRenderer, error) {
-	if concurrent {
-		return newConcurrentBatch()
+// putVertices writes vertices v to dst, using texture unit tex.
+//
+func putVertices(dst []packedVertex, v []Vertex, tex uint8) {
+	dst = dst[:len(v)]
+	for i := range v {
+		v := &v[i]
+		dst[i] = packedVertex{x: v.Pos.X, y: v.Pos.Y, u: v.UV.X, v: v.UV.Y, c: packGLColor(v.Color), tex: tex, w: packWrap(&v.Wrap)}
 	}
-	return newBatch()
 }
 
-// A batch draws sprites in batches.
+// maxTriangleVertices returns the maximum number of triangle vertices that fit
+// in a batch of the given number of quad slots.
 //
-type batch struct {
-	program gl.Program
-	attr    struct {
-		pos   uint32
-		color uint32
+func maxTriangleVertices(slots int) int {
+	return slots * 4 / 3 * 3
+}
+
+// appendMeshIndices appends the indices of a mesh of n vertices starting at
+// vertex base to dst. If indices is nil, consecutive vertices form triangles.
+//
+func appendMeshIndices(dst []uint32, base uint32, n int, indices []uint16) []uint32 {
+	if indices == nil {
+		for i := uint32(0); i < uint32(n); i++ {
+			dst = append(dst, base+i)
+		}
+		return dst
+	}
+	for _, i := range indices {
+		dst = append(dst, base+uint32(i))
+	}
+	return dst
+}
+
+// quadOrder returns the vertices of q, given in perimeter order, in the order
+// expected by the batch index buffer.
+//
+func quadOrder(q *[4]Vertex) [4]Vertex {
+	return [4]Vertex{q[0], q[1], q[3], q[2]}
+}
+
+func batchBegin(va *vertexArray, m *Material, proj *[16]float32, blend BlendMode) {
+	va.bind(m)
+	m.use(proj)
+	blend.apply()
+}
+
+// batchConfig holds the options of a batch.
+//
+type batchConfig struct {
+	concurrent bool
+	instanced  bool
+	capacity   int
+	workers    int
+	threshold  int
+}
+
+func newBatchConfig(opts ...BatchOption) batchConfig {
+	c := batchConfig{
+		capacity:  batchSize,
+		workers:   runtime.NumCPU(),
+		threshold: dispatchThreshold,
 	}
-	uniform struct {
-		cam int32
-		tex int32
+	for _, o := range opts {
+		o.set(&c)
 	}
-	vbo   uint32
-	ebo   uint32
-	index int
+	return c
+}
 
-	vertices []float32
-	texture  Drawable
+// BatchOption is implemented by functions setting batch options. See NewBatch.
+//
+type BatchOption interface {
+	set(*batchConfig)
+}
+
+type batchOptionFunc func(*batchConfig)
+
+func (f batchOptionFunc) set(c *batchConfig) {
+	f(c)
+}
+
+// Concurrent selects the concurrent batch implementation if c is true: model
+// transformations are computed by worker goroutines concurrently with
+// drawing. The default is the synchronous implementation.
+//
+func Concurrent(c bool) BatchOption {
+	return batchOptionFunc(func(bc *batchConfig) {
+		bc.concurrent = c
+	})
+}
+
+// Instanced selects the instanced batch implementation if i is true and
+// instanced rendering is available. Instead of four vertices computed by the
+// CPU, each Draw call adds a single 52-byte record to the batch; quads are
+// expanded in the vertex shader.
+//
+// Instanced rendering requires OpenGL 3.3 or OpenGLES 3.0, and extension
+// functions loaded with gl.InitExtC or gl.InitExtGo. When it is not available,
+// the option is ignored. Otherwise, Concurrent, Workers and DispatchThreshold
+// are ignored, and Capacity is the number of sprites drawn per flush.
+//
+// The instanced batch only draws sprites. Apart from BatchRenderer, it
+// implements StatsRenderer, CullingRenderer, SnapRenderer and UploadRenderer,
+// but not MaterialRenderer, LayeredRenderer, ShapeRenderer, MeshRenderer,
+// ClipRenderer or ParallelRenderer: custom materials, sorted mode, shapes,
+// meshes, clip masks and command buffers are not available. Use type
+// assertions to check for these features, and do not use Instanced if they are
+// needed.
+//
+func Instanced(i bool) BatchOption {
+	return batchOptionFunc(func(c *batchConfig) {
+		c.instanced = i
+	})
+}
+
+// Capacity sets the maximum number of quads drawn per flush. The default is
+// 5000. With 16-bit element indices, capacity is limited to 16383 quads.
+// Values lower than 1 are ignored.
+//
+func Capacity(quads int) BatchOption {
+	return batchOptionFunc(func(c *batchConfig) {
+		if quads > 0 {
+			c.capacity = quads
+		}
+	})
+}
+
+// Workers sets the number of goroutines that compute model transformations in
+// concurrent batches. The default is runtime.NumCPU(). Values lower than 1 are
+// treated as 1: a single goroutine computes all transformations.
+//
+func Workers(n int) BatchOption {
+	return batchOptionFunc(func(c *batchConfig) {
+		if n < 1 {
+			n = 1
+		}
+		c.workers = n
+	})
+}
+
+// DispatchThreshold sets the number of draw commands in a buffer above which
+// concurrent batches split the computation of model transformations between
+// workers. The default is 500. Values lower than 1 are treated as 1: all
+// buffers with more than one draw command are split.
+//
+func DispatchThreshold(n int) BatchOption {
+	return batchOptionFunc(func(c *batchConfig) {
+		if n < 1 {
+			n = 1
+		}
+		c.threshold = n
+	})
+}
+
+// NewBatch returns a new BatchRenderer configured with the given options. See
+// Concurrent, Instanced, Capacity, Workers and DispatchThreshold.
+//
+// With OpenGL core profile contexts, extension functions must have been loaded
+// with gl.InitExtC or gl.InitExtGo.
+//
+func NewBatch(opts ...BatchOption) (BatchRenderer, error) {
+	if gl.RuntimeCoreProfile() && !gl.HasVertexArrays() {
+		return nil, xerrors.New("core profile context without vertex array objects: extension functions not loaded")
+	}
+	c := newBatchConfig(opts...)
+	if c.instanced && gl.HasInstancing() && gl.HasVertexArrays() {
+		return newInstancedBatch(&c)
+	}
+	if c.concurrent {
+		return newConcurrentBatch(&c)
+	}
+	return newBatch(&c)
+}
+
+// A batch draws sprites in batches.
+//
+type batch struct {
+	material *Material // current material
+	def      *Material // default material
+	mask     *Material // clip mask material
+	white    *Texture  // white texture for DrawTriangles
+	va       vertexArray
+	index    int // number of quad slots in use
+	size     int // max number of quad slots
+	ifmt     indexFormat
+
+	vertices []packedVertex
+	indices  []uint32 // custom indices
+	custom   bool     // true if the current batch uses custom indices
+	textures textureSet
 	proj     [16]float32
+	blend    BlendMode
+	layers   layerQueue
+	stats    Stats
+	buffers  cmdBuffers
+	cull     culler
+	snap     snapper
+	clip     clipStack
+	target   targetBinding
 }
 
-func newBatch() (*batch, error) {
+func newBatch(c *batchConfig) (*batch, error) {
 	var (
 		b   = new(batch)
 		err error
 	)
-	b.program, err = loadShaders()
-	if err != nil {
-		return nil, err
-	}
-	b.attr.pos, err = b.program.AttribLocation("aPos")
+	b.def, err = newDefaultMaterial()
 	if err != nil {
 		return nil, err
 	}
-	b.attr.color, err = b.program.AttribLocation("aColor")
+	b.mask, err = newMaskMaterial()
 	if err != nil {
+		b.def.Delete()
 		return nil, err
 	}
-	b.uniform.cam = b.program.UniformLocation("uProjection")
-	b.uniform.tex = b.program.UniformLocation("uTexture")
-	gl.GenBuffers(1, &b.vbo)
-	gl.GenBuffers(1, &b.ebo)
+	b.material = b.def
+	b.white = newWhiteTexture()
 
-	b.vertices = make([]float32, batchSize*floatsPerQuad)
-	batchInit(b.vbo, b.ebo)
+	b.ifmt = newIndexFormat()
+	b.size = b.ifmt.maxQuads(c.capacity)
+	b.vertices = make([]packedVertex, 0, b.size*4)
+	b.textures = newTextureSet(b.def.units)
+	b.va = newVertexArray(&b.ifmt, b.size)
+	batchInit()
 
 	return b, nil
 }
@@ -132,40 +407,116 @@ func (b *batch) Begin() {
 	if b.index != 0 {
 		panic("call Flush() before Begin()")
 	}
-	batchBegin(b.vbo, b.ebo, b.program, b.attr.pos, b.attr.color, b.uniform.tex)
+	b.stats = Stats{}
+	batchBegin(&b.va, b.material, &b.proj, b.blend)
+	if b.clip.reset() {
+		clipState{}.apply()
+	}
+}
+
+// Stats returns the rendering statistics since the last call to Begin.
+//
+func (b *batch) Stats() Stats {
+	return b.stats
 }
 
 // Camera sets the camera for world to screen transforms and clipping region.
 //
 func (b *batch) Camera(c Camera) {
-	if b.index != 0 {
-		b.Flush()
-	}
+	b.flushAll(flushCamera)
+	b.target.bind(cameraTarget(c))
 	proj := c.ProjectionMatrix()
-	gl.UniformMatrix4fv(b.uniform.cam, 1, gl.GL_FALSE, &proj[0])
+	gl.UniformMatrix4fv(b.material.uniform.cam, 1, gl.GL_FALSE, &proj[0])
 	b.proj = proj
 	r := c.GLRect()
 	gl.Scissor(int32(r.Min.X), int32(r.Min.Y), int32(r.Dx()), int32(r.Dy()))
+	b.cull.setCamera(c)
+	b.snap.setCamera(c)
+}
+
+// SetMaterial sets the material used for subsequent draw calls. A nil material
+// selects the default material.
+//
+func (b *batch) SetMaterial(m *Material) {
+	if m == nil {
+		m = b.def
+	}
+	if m == b.material {
+		return
+	}
+	b.flushAll(flushState)
+	b.material = m
+	b.textures.limit = m.units
+	b.va.setMaterial(m)
+	m.use(&b.proj)
+}
+
+// SetBlendMode sets the blend mode for subsequent draw calls.
+//
+func (b *batch) SetBlendMode(m BlendMode) {
+	if m == b.blend {
+		return
+	}
+	b.flushAll(flushState)
+	b.blend = m
+	m.apply()
+}
+
+// SetUniform sets the value of the named uniform in the current material.
+//
+func (b *batch) SetUniform(name string, v ...float32) {
+	gl.CheckUniform(v)
+	b.flushAll(flushState)
+	gl.SetUniform(b.material.location(name), v)
+}
+
+// SetSorted enables or disables sorted mode. See LayeredRenderer.
+//
+func (b *batch) SetSorted(sorted bool) {
+	if sorted == b.layers.sorted {
+		return
+	}
+	b.layers.drain(b)
+	b.layers.sorted = sorted
 }
 
 func (b *batch) Draw(d Drawable, dp, scale Point, rot float32, c color.Color) {
-	if b.index >= batchSize {
-		b.Flush()
+	if b.layers.sorted {
+		b.layers.push(0, d, dp, scale, rot, c)
+		return
 	}
+	b.draw(d, dp, scale, rot, c)
+}
 
-	if b.index == 0 {
-		b.texture = d
-	} else if b.texture.NativeID() != d.NativeID() {
-		b.Flush()
-		b.texture = d
+// DrawLayer draws d in the given layer. Outside of sorted mode, the layer is
+// ignored and d is drawn immediately.
+//
+func (b *batch) DrawLayer(layer int, d Drawable, dp, scale Point, rot float32, c color.Color) {
+	if b.layers.sorted {
+		b.layers.push(layer, d, dp, scale, rot, c)
+		return
 	}
+	b.draw(d, dp, scale, rot, c)
+}
 
-	var rf, gf, bf, af float32 = 1.0, 1.0, 1.0, 1.0
-	if c != nil {
-		c := gl.ColorModel.Convert(c).(gl.Color)
-		rf, gf, bf, af = c.R, c.G, c.B, c.A
+func (b *batch) draw(d Drawable, dp, scale Point, rot float32, c color.Color) {
+	dp, scale = b.snap.sprite(d, dp, scale, rot)
+	if b.cull.sprite(d, dp, scale, rot) {
+		b.stats.Culled++
+		return
+	}
+	if b.index >= b.size {
+		b.flush(flushFull)
 	}
 
+	ti := b.textures.index(d)
+	if ti < 0 {
+		b.flush(flushTexture)
+		ti = b.textures.index(d)
+	}
+	tex := uint8(ti)
+	pc := packColor(c)
+
 	// optimized version of ngl32 matrix transforms => +25% ups
 	var m0, m1, m3, m4 float32 = 1, 0, 0, 1
 	if rot != 0 {
@@ -187,35 +538,266 @@ func (b *batch) Draw(d Drawable, dp, scale Point, rot float32, c color.Color) {
 	uv := d.UV()
 	b.vertices = append(b.vertices,
 		// top left
-		m3+m6, m4+m7, uv[0], uv[1], rf, gf, bf, af,
+		packedVertex{x: m3 + m6, y: m4 + m7, u: uv[0], v: uv[1], c: pc, tex: tex},
 		// top right
-		m0+m3+m6, m1+m4+m7, uv[2], uv[1], rf, gf, bf, af,
+		packedVertex{x: m0 + m3 + m6, y: m1 + m4 + m7, u: uv[2], v: uv[1], c: pc, tex: tex},
 		// bottom left
-		m6, m7, uv[0], uv[3], rf, gf, bf, af,
+		packedVertex{x: m6, y: m7, u: uv[0], v: uv[3], c: pc, tex: tex},
 		// bottom right
-		m0+m6, m1+m7, uv[2], uv[3], rf, gf, bf, af,
+		packedVertex{x: m0 + m6, y: m1 + m7, u: uv[2], v: uv[3], c: pc, tex: tex},
 	)
+	if b.custom {
+		b.indices = appendQuadIndices(b.indices, b.index, 1)
+	}
 	b.index++
 }
 
+// DrawTriangles draws textured triangles. See ShapeRenderer.
+//
+func (b *batch) DrawTriangles(d Drawable, v []Vertex) {
+	b.DrawTrianglesLayer(0, d, v)
+}
+
+// DrawTrianglesLayer draws textured triangles in the given layer. See
+// LayeredMeshRenderer.
+//
+func (b *batch) DrawTrianglesLayer(layer int, d Drawable, v []Vertex) {
+	if len(v) < 3 {
+		return
+	}
+	if d == nil {
+		d = b.white
+	}
+	if b.layers.sorted {
+		b.layers.pushVertices(primTriangles, layer, d, v, nil)
+		return
+	}
+	b.triangles(d, v)
+}
+
+func (b *batch) triangles(d Drawable, v []Vertex) {
+	max := maxTriangleVertices(b.size)
+	for len(v) >= 3 {
+		n := len(v) / 3 * 3
+		if n > max {
+			n = max
+		}
+		b.indexed(d, v[:n], nil)
+		v = v[n:]
+	}
+}
+
+// DrawQuad draws a textured quad. See MeshRenderer.
+//
+func (b *batch) DrawQuad(d Drawable, q *[4]Vertex) {
+	b.DrawQuadLayer(0, d, q)
+}
+
+// DrawQuadLayer draws a textured quad in the given layer. See
+// LayeredMeshRenderer.
+//
+func (b *batch) DrawQuadLayer(layer int, d Drawable, q *[4]Vertex) {
+	if d == nil {
+		d = b.white
+	}
+	if b.layers.sorted {
+		b.layers.pushVertices(primQuad, layer, d, q[:], nil)
+		return
+	}
+	b.quad(d, q)
+}
+
+func (b *batch) quad(d Drawable, q *[4]Vertex) {
+	v := quadOrder(q)
+	b.putQuad(d, &v)
+}
+
+// DrawMesh draws a textured triangle mesh. See MeshRenderer.
+//
+func (b *batch) DrawMesh(d Drawable, v []Vertex, indices []uint16) {
+	b.DrawMeshLayer(0, d, v, indices)
+}
+
+// DrawMeshLayer draws a textured triangle mesh in the given layer. See
+// LayeredMeshRenderer.
+//
+func (b *batch) DrawMeshLayer(layer int, d Drawable, v []Vertex, indices []uint16) {
+	checkMesh(len(v), indices)
+	if len(indices) == 0 {
+		return
+	}
+	if d == nil {
+		d = b.white
+	}
+	if b.layers.sorted {
+		b.layers.pushVertices(primMesh, layer, d, v, indices)
+		return
+	}
+	b.mesh(d, v, indices)
+}
+
+func (b *batch) mesh(d Drawable, v []Vertex, indices []uint16) {
+	if meshSlots(len(v)) > b.size {
+		b.triangles(d, expandMesh(v, indices))
+		return
+	}
+	b.indexed(d, v, indices)
+}
+
+// indexed adds the vertices of a triangle mesh to the batch. If indices is
+// nil, the triangles are formed by consecutive vertices.
+//
+func (b *batch) indexed(d Drawable, v []Vertex, indices []uint16) {
+	slots := meshSlots(len(v))
+	if b.cull.vertices(v) {
+		b.stats.Culled += slots
+		return
+	}
+	first := b.put(d, v, slots)
+	if !b.custom {
+		b.custom = true
+		b.indices = appendQuadIndices(b.indices[:0], 0, first)
+	}
+	b.indices = appendMeshIndices(b.indices, uint32(first*4), len(v), indices)
+}
+
+// putQuad adds the vertices of a quad to the batch.
+//
+func (b *batch) putQuad(d Drawable, q *[4]Vertex) {
+	if b.cull.vertices(q[:]) {
+		b.stats.Culled++
+		return
+	}
+	first := b.put(d, q[:], 1)
+	if b.custom {
+		b.indices = appendQuadIndices(b.indices, first, 1)
+	}
+}
+
+// put adds vertices v to the batch, using the given number of quad slots. It
+// returns the first slot used.
+//
+func (b *batch) put(d Drawable, v []Vertex, slots int) int {
+	if b.index+slots > b.size {
+		b.flush(flushFull)
+	}
+	ti := b.textures.index(d)
+	if ti < 0 {
+		b.flush(flushTexture)
+		ti = b.textures.index(d)
+	}
+	n := len(b.vertices)
+	b.vertices = b.vertices[:n+slots*4]
+	putVertices(b.vertices[n:], v, uint8(ti))
+	first := b.index
+	b.index += slots
+	return first
+}
+
+// BeginClip starts recording a clip mask. See ClipRenderer.
+//
+func (b *batch) BeginClip() {
+	s := b.clip.begin(b.material)
+	b.flushAll(flushState)
+	s.apply()
+	b.SetMaterial(b.mask)
+}
+
+// EndClip ends recording a clip mask and puts it in effect. See ClipRenderer.
+//
+func (b *batch) EndClip() {
+	s, m := b.clip.end()
+	b.flushAll(flushState)
+	s.apply()
+	b.SetMaterial(m)
+}
+
+// PopClip removes the last clip mask. See ClipRenderer.
+//
+func (b *batch) PopClip() {
+	remove, s, ok := b.clip.pop()
+	b.flushAll(flushState)
+	if ok {
+		m := b.material
+		remove.apply()
+		b.SetMaterial(b.mask)
+		q := b.cull.clipRegion()
+		b.putQuad(b.white, &q)
+		b.SetMaterial(m)
+	}
+	s.apply()
+}
+
+// SetPixelSnap enables or disables pixel snapping. See SnapRenderer.
+//
+func (b *batch) SetPixelSnap(enabled bool) {
+	b.layers.drain(b)
+	b.snap.setEnabled(enabled)
+}
+
+// SetCulling enables or disables culling. See CullingRenderer.
+//
+func (b *batch) SetCulling(enabled bool) {
+	b.cull.disabled = !enabled
+}
+
+// CommandBuffer returns the command buffer identified by order.
+//
+func (b *batch) CommandBuffer(order int) *DisplayList {
+	return b.buffers.get(order)
+}
+
 func (b *batch) Flush() {
+	b.buffers.replay(b)
+	b.flushAll(flushEnd)
+}
+
+// flushAll draws all pending draw calls, including queued draw calls in sorted
+// mode.
+//
+func (b *batch) flushAll(r flushReason) {
+	b.layers.drain(b)
+	b.flush(r)
+}
+
+func (b *batch) flush(r flushReason) {
 	if b.index == 0 {
 		return
 	}
-	b.texture.Bind()
+	b.stats.flush(r, b.index)
+	b.stats.DrawCalls++
+	b.textures.bind()
 
-	gl.BufferSubData(gl.GL_ARRAY_BUFFER, 0, b.index*floatsPerQuad*4, gl.Ptr(&b.vertices[0]))
-	gl.DrawElements(gl.GL_TRIANGLES, int32(b.index*indicesPerQuad), gl.GL_UNSIGNED_INT, nil)
+	first := b.va.write(b.vertices)
+	b.stats.Uploaded += b.index * quadSize
+	if b.custom {
+		b.stats.Uploaded += b.va.drawIndexed(b.indices, first, &b.ifmt)
+		b.custom = false
+		b.indices = b.indices[:0]
+	} else {
+		b.va.drawQuads(first, b.index, &b.ifmt)
+	}
 	b.index = 0
 	b.vertices = b.vertices[:0]
+	b.textures.reset()
+}
+
+// SetUploadStrategy flushes the batch and sets the vertex upload strategy.
+//
+func (b *batch) SetUploadStrategy(s UploadStrategy) UploadStrategy {
+	b.flushAll(flushState)
+	s = b.va.setUpload(s, b.size)
+	b.va.bind(b.material)
+	return s
 }
 
 func (b *batch) End() {
 	b.Flush()
+	b.target.bind(nil)
 }
 
 func (b *batch) Clear(c color.Color) {
-	b.Flush()
+	b.flushAll(flushCamera)
 	if c != nil {
 		c := gl.ColorModel.Convert(c).(gl.Color)
 		gl.ClearColor(c.R, c.G, c.B, c.A)
@@ -224,7 +806,8 @@ func (b *batch) Clear(c color.Color) {
 }
 
 func (b *batch) Close() {
-	b.program.Delete()
-	gl.DeleteBuffers(1, &b.ebo)
-	gl.DeleteBuffers(1, &b.vbo)
+	b.def.Delete()
+	b.mask.Delete()
+	b.white.Delete()
+	b.va.delete()
 }
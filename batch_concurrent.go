@@ -4,7 +4,6 @@ import (
 	"image"
 	"image/color"
 	"math"
-	"runtime"
 	"sync"
 
 	"github.com/db47h/grog/gl"
@@ -16,88 +15,128 @@ type drawCmd struct {
 	scaleX, scaleY float32
 	rot            float32
 	c              color.Color
+	tex            uint8    // texture unit
+	v              []Vertex // DrawTriangles, DrawQuad and DrawMesh vertices
 }
 
 type work struct {
 	cmds     []drawCmd
-	vertices []float32
+	vertices []packedVertex
+}
+
+type opKind int
+
+const (
+	opCamera opKind = iota
+	opMaterial
+	opUniform
+	opBlend
+	opClear
+	opClip
+)
+
+// A batchOp is a state change queued in a concurrentBatch buffer. Queued
+// state changes are applied in order before drawing the buffer's vertices.
+//
+type batchOp struct {
+	kind   opKind
+	proj   [16]float32     // opCamera
+	view   image.Rectangle // opCamera
+	target *RenderTarget   // opCamera
+	m      *Material       // opMaterial
+	loc    int32           // opUniform
+	n      int             // opUniform
+	v      [16]float32     // opUniform
+	mode   BlendMode       // opBlend
+	c      gl.Color        // opClear
+	setC   bool            // opClear: true if c must be set as the clear color
+	clip   clipState       // opClip
 }
 
 // A concurrentBatch draws sprites in batches and computes model transformations concurrently.
 //
 type concurrentBatch struct {
-	program gl.Program
-	attr    struct {
-		pos   uint32
-		color uint32
-	}
-	uniform struct {
-		cam int32
-		tex int32
-	}
-	vbo   uint32
-	ebo   uint32
-	index int
+	material   *Material // current material for draw calls
+	glMaterial *Material // material currently bound in the GL context
+	def        *Material // default material
+	mask       *Material // clip mask material
+	white      *Texture  // white texture for DrawTriangles
+	va         vertexArray
+	index      int // number of quad slots in use
+	size       int // max number of quad slots
+	ifmt       indexFormat
+	proj       [16]float32
+	blend      BlendMode // current blend mode for draw calls
+	glBlend    BlendMode // blend mode currently set in the GL context
+	layers     layerQueue
+	stats      Stats
+	buffers    cmdBuffers
+	cull       culler
+	snap       snapper
+	clip       clipStack
+	target     targetBinding // RenderTarget bound in the GL context
 
 	drawChan   chan []drawCmd
-	vertexChan chan []float32
+	vertexChan chan []packedVertex
 	inFlight   int
 	cb         int
 	buf        [2]struct {
-		cmds    [batchSize]drawCmd
-		texture Drawable
-		proj    []float32
-		view    image.Rectangle
-		updView bool
+		cmds     []drawCmd
+		verts    []Vertex // storage for drawCmd.v
+		indices  []uint32 // custom indices
+		custom   bool     // true if the buffer uses custom indices
+		textures textureSet
+		ops      []batchOp
 	}
 }
 
-func newConcurrentBatch() (*concurrentBatch, error) {
+func newConcurrentBatch(c *batchConfig) (*concurrentBatch, error) {
 	var (
 		b = &concurrentBatch{
 			drawChan:   make(chan []drawCmd, 1),
-			vertexChan: make(chan []float32),
+			vertexChan: make(chan []packedVertex),
 		}
 		err error
 	)
-	b.buf[0].proj = make([]float32, 16)
-	b.buf[1].proj = make([]float32, 16)
-
-	b.program, err = loadShaders()
-	if err != nil {
-		return nil, err
-	}
-	b.attr.pos, err = b.program.AttribLocation("aPos")
+	b.def, err = newDefaultMaterial()
 	if err != nil {
 		return nil, err
 	}
-	b.attr.color, err = b.program.AttribLocation("aColor")
+	b.mask, err = newMaskMaterial()
 	if err != nil {
+		b.def.Delete()
 		return nil, err
 	}
-	b.uniform.cam = b.program.UniformLocation("uProjection")
-	b.uniform.tex = b.program.UniformLocation("uTexture")
-	gl.GenBuffers(1, &b.vbo)
-	gl.GenBuffers(1, &b.ebo)
+	b.material, b.glMaterial = b.def, b.def
+	b.white = newWhiteTexture()
+	b.buf[0].textures = newTextureSet(b.def.units)
+	b.buf[1].textures = newTextureSet(b.def.units)
 
-	batchInit(b.vbo, b.ebo)
+	b.ifmt = newIndexFormat()
+	b.size = b.ifmt.maxQuads(c.capacity)
+	b.buf[0].cmds = make([]drawCmd, b.size)
+	b.buf[1].cmds = make([]drawCmd, b.size)
+	b.va = newVertexArray(&b.ifmt, b.size)
+	batchInit()
 
-	go worker(b.drawChan, b.vertexChan)
+	go worker(b.drawChan, b.vertexChan, b.size, c.workers, c.threshold)
 
 	return b, nil
 }
 
-func worker(in <-chan []drawCmd, out chan<- []float32) {
+// worker computes the vertices of draw commands received from in, for up to
+// size quads per buffer. Above th commands, the work is split between the
+// worker itself and workers-1 child goroutines.
+//
+func worker(in <-chan []drawCmd, out chan<- []packedVertex, size, workers, th int) {
 	var (
-		buf     int
-		v       [2][batchSize * floatsPerQuad]float32
-		wg      sync.WaitGroup
-		wc          = make(chan work)
-		th      int = 500 // threshold for dispatching to child workers
-		workers     = runtime.NumCPU()
+		buf int
+		v   = [2][]packedVertex{make([]packedVertex, size*4), make([]packedVertex, size*4)}
+		wg  sync.WaitGroup
+		wc  = make(chan work)
 	)
 
-	for i := 0; i < workers; i++ {
+	for i := 1; i < workers; i++ {
 		go func() {
 			for wi := range wc {
 				processCmds(wi.cmds, wi.vertices)
@@ -109,38 +148,44 @@ func worker(in <-chan []drawCmd, out chan<- []float32) {
 	for cmds := range in {
 		start := 0
 		count := len(cmds)
-		if count > th {
+		if workers > 1 && count > th {
 			count = (count + workers - 1) / workers
 			for start < len(cmds) {
 				end := start + count
-				if end > len(cmds) {
-					end = len(cmds)
-				}
-				if end > start {
-					wg.Add(1)
-					wc <- work{cmds: cmds[start:end], vertices: v[buf][start*floatsPerQuad : end*floatsPerQuad]}
-					start = end
+				if end >= len(cmds) {
+					// last chunk: process it in this goroutine
+					processCmds(cmds[start:], v[buf][start*4:])
+					break
 				}
+				wg.Add(1)
+				wc <- work{cmds: cmds[start:end], vertices: v[buf][start*4 : end*4]}
+				start = end
 			}
 			wg.Wait()
 		} else {
-			processCmds(cmds, v[buf][:])
+			processCmds(cmds, v[buf])
 		}
-		out <- v[buf][:len(cmds)*floatsPerQuad]
+		out <- v[buf][:len(cmds)*4]
 		buf ^= 1
 	}
 	close(wc)
 }
 
-func processCmds(cmds []drawCmd, vertices []float32) {
+func processCmds(cmds []drawCmd, vertices []packedVertex) {
 	for i := range cmds {
 		d := &cmds[i]
-
-		var rf, gf, bf, af float32 = 1, 1, 1, 1
-		if d.c != nil {
-			c := gl.ColorModel.Convert(d.c).(gl.Color)
-			rf, gf, bf, af = c.R, c.G, c.B, c.A
+		if d.v != nil {
+			// meshes may span several slots, possibly beyond the end of
+			// vertices when work is split between workers. The following
+			// commands are empty placeholders.
+			putVertices(vertices[i*4:cap(vertices)], d.v, d.tex)
+			continue
 		}
+		if d.d == nil {
+			continue
+		}
+
+		pc := packColor(d.c)
 
 		// optimized version of ngl32 matrix transforms => +25% ups
 		var m0, m1, m3, m4 float32 = 1, 0, 0, 1
@@ -161,16 +206,16 @@ func processCmds(cmds []drawCmd, vertices []float32) {
 		m4 *= sY
 
 		uv := d.d.UV()
-		copy(vertices[i*floatsPerQuad:], []float32{
-			// top left
-			m3 + m6, m4 + m7, uv[0], uv[1], rf, gf, bf, af,
-			// top right
-			m0 + m3 + m6, m1 + m4 + m7, uv[2], uv[1], rf, gf, bf, af,
-			// bottom left
-			m6, m7, uv[0], uv[3], rf, gf, bf, af,
-			// bottom right
-			m0 + m6, m1 + m7, uv[2], uv[3], rf, gf, bf, af,
-		})
+		tex := d.tex
+		q := vertices[i*4 : i*4+4 : i*4+4]
+		// top left
+		q[0] = packedVertex{x: m3 + m6, y: m4 + m7, u: uv[0], v: uv[1], c: pc, tex: tex}
+		// top right
+		q[1] = packedVertex{x: m0 + m3 + m6, y: m1 + m4 + m7, u: uv[2], v: uv[1], c: pc, tex: tex}
+		// bottom left
+		q[2] = packedVertex{x: m6, y: m7, u: uv[0], v: uv[3], c: pc, tex: tex}
+		// bottom right
+		q[3] = packedVertex{x: m0 + m6, y: m1 + m7, u: uv[2], v: uv[3], c: pc, tex: tex}
 	}
 }
 
@@ -178,47 +223,396 @@ func (b *concurrentBatch) Begin() {
 	if b.index != 0 || b.inFlight > 0 {
 		panic("call End() before Begin()")
 	}
-	batchBegin(b.vbo, b.ebo, b.program, b.attr.pos, b.attr.color, b.uniform.tex)
+	b.stats = Stats{}
+	batchBegin(&b.va, b.glMaterial, &b.proj, b.glBlend)
+	if b.clip.reset() {
+		clipState{}.apply()
+	}
+}
+
+// Stats returns the rendering statistics since the last call to Begin.
+//
+func (b *concurrentBatch) Stats() Stats {
+	return b.stats
 }
 
 // Camera sets the camera for world to screen transforms and clipping region.
 //
 func (b *concurrentBatch) Camera(c Camera) {
+	b.layers.drain(b)
+	if b.index != 0 {
+		b.flush(flushCamera)
+	}
+	b.queue(batchOp{kind: opCamera, proj: c.ProjectionMatrix(), view: c.GLRect(), target: cameraTarget(c)})
+	b.cull.setCamera(c)
+	b.snap.setCamera(c)
+}
+
+// SetMaterial sets the material used for subsequent draw calls. A nil material
+// selects the default material.
+//
+func (b *concurrentBatch) SetMaterial(m *Material) {
+	if m == nil {
+		m = b.def
+	}
+	if m == b.material {
+		return
+	}
+	b.layers.drain(b)
+	if b.index != 0 {
+		b.flush(flushState)
+	}
+	b.material = m
+	b.buf[b.cb].textures.limit = m.units
+	b.queue(batchOp{kind: opMaterial, m: m})
+}
+
+// SetBlendMode sets the blend mode for subsequent draw calls.
+//
+func (b *concurrentBatch) SetBlendMode(m BlendMode) {
+	if m == b.blend {
+		return
+	}
+	b.layers.drain(b)
+	if b.index != 0 {
+		b.flush(flushState)
+	}
+	b.blend = m
+	b.queue(batchOp{kind: opBlend, mode: m})
+}
+
+// SetUniform sets the value of the named uniform in the current material.
+//
+func (b *concurrentBatch) SetUniform(name string, v ...float32) {
+	gl.CheckUniform(v)
+	b.layers.drain(b)
 	if b.index != 0 {
-		b.flush()
+		b.flush(flushState)
 	}
-	proj := c.ProjectionMatrix()
-	copy(b.buf[b.cb].proj, proj[:])
-	b.buf[b.cb].view = c.GLRect()
-	b.buf[b.cb].updView = true
+	op := batchOp{kind: opUniform, loc: b.material.location(name), n: len(v)}
+	copy(op.v[:], v)
+	b.queue(op)
+}
+
+// queue queues a state change in the current buffer.
+//
+func (b *concurrentBatch) queue(op batchOp) {
+	b.buf[b.cb].ops = append(b.buf[b.cb].ops, op)
+}
+
+// apply applies a queued state change.
+//
+func (b *concurrentBatch) apply(op *batchOp) {
+	switch op.kind {
+	case opCamera:
+		b.target.bind(op.target)
+		v := op.view
+		gl.Scissor(int32(v.Min.X), int32(v.Min.Y), int32(v.Dx()), int32(v.Dy()))
+		b.proj = op.proj
+		gl.UniformMatrix4fv(b.glMaterial.uniform.cam, 1, gl.GL_FALSE, &b.proj[0])
+	case opMaterial:
+		b.glMaterial = op.m
+		b.va.setMaterial(op.m)
+		op.m.use(&b.proj)
+	case opUniform:
+		gl.SetUniform(op.loc, op.v[:op.n])
+	case opBlend:
+		b.glBlend = op.mode
+		op.mode.apply()
+	case opClear:
+		if op.setC {
+			gl.ClearColor(op.c.R, op.c.G, op.c.B, op.c.A)
+		}
+		gl.Clear(gl.GL_COLOR_BUFFER_BIT)
+	case opClip:
+		op.clip.apply()
+	}
+}
+
+// SetSorted enables or disables sorted mode. See LayeredRenderer.
+//
+func (b *concurrentBatch) SetSorted(sorted bool) {
+	if sorted == b.layers.sorted {
+		return
+	}
+	b.layers.drain(b)
+	b.layers.sorted = sorted
 }
 
 func (b *concurrentBatch) Draw(d Drawable, dp, scale Point, rot float32, c color.Color) {
-	if b.index >= batchSize {
-		b.flush()
+	if b.layers.sorted {
+		b.layers.push(0, d, dp, scale, rot, c)
+		return
+	}
+	b.draw(d, dp, scale, rot, c)
+}
+
+// DrawLayer draws d in the given layer. Outside of sorted mode, the layer is
+// ignored and d is drawn immediately.
+//
+func (b *concurrentBatch) DrawLayer(layer int, d Drawable, dp, scale Point, rot float32, c color.Color) {
+	if b.layers.sorted {
+		b.layers.push(layer, d, dp, scale, rot, c)
+		return
+	}
+	b.draw(d, dp, scale, rot, c)
+}
+
+func (b *concurrentBatch) draw(d Drawable, dp, scale Point, rot float32, c color.Color) {
+	dp, scale = b.snap.sprite(d, dp, scale, rot)
+	if b.cull.sprite(d, dp, scale, rot) {
+		b.stats.Culled++
+		return
+	}
+	if b.index >= b.size {
+		b.flush(flushFull)
 	}
 
-	if b.index == 0 {
-		b.buf[b.cb].texture = d
-	} else if b.buf[b.cb].texture.NativeID() != d.NativeID() {
-		b.flush()
-		b.buf[b.cb].texture = d
+	ti := b.buf[b.cb].textures.index(d)
+	if ti < 0 {
+		b.flush(flushTexture)
+		ti = b.buf[b.cb].textures.index(d)
 	}
 
-	b.buf[b.cb].cmds[b.index] = drawCmd{d, dp.X, dp.Y, scale.X, scale.Y, rot, c}
+	cb := &b.buf[b.cb]
+	cb.cmds[b.index] = drawCmd{d, dp.X, dp.Y, scale.X, scale.Y, rot, c, uint8(ti), nil}
+	if cb.custom {
+		cb.indices = appendQuadIndices(cb.indices, b.index, 1)
+	}
 	b.index++
 }
 
+// DrawTriangles draws textured triangles. See ShapeRenderer.
+//
+func (b *concurrentBatch) DrawTriangles(d Drawable, v []Vertex) {
+	b.DrawTrianglesLayer(0, d, v)
+}
+
+// DrawTrianglesLayer draws textured triangles in the given layer. See
+// LayeredMeshRenderer.
+//
+func (b *concurrentBatch) DrawTrianglesLayer(layer int, d Drawable, v []Vertex) {
+	if len(v) < 3 {
+		return
+	}
+	if d == nil {
+		d = b.white
+	}
+	if b.layers.sorted {
+		b.layers.pushVertices(primTriangles, layer, d, v, nil)
+		return
+	}
+	b.triangles(d, v)
+}
+
+func (b *concurrentBatch) triangles(d Drawable, v []Vertex) {
+	max := maxTriangleVertices(b.size)
+	for len(v) >= 3 {
+		n := len(v) / 3 * 3
+		if n > max {
+			n = max
+		}
+		b.indexed(d, v[:n], nil)
+		v = v[n:]
+	}
+}
+
+// DrawQuad draws a textured quad. See MeshRenderer.
+//
+func (b *concurrentBatch) DrawQuad(d Drawable, q *[4]Vertex) {
+	b.DrawQuadLayer(0, d, q)
+}
+
+// DrawQuadLayer draws a textured quad in the given layer. See
+// LayeredMeshRenderer.
+//
+func (b *concurrentBatch) DrawQuadLayer(layer int, d Drawable, q *[4]Vertex) {
+	if d == nil {
+		d = b.white
+	}
+	if b.layers.sorted {
+		b.layers.pushVertices(primQuad, layer, d, q[:], nil)
+		return
+	}
+	b.quad(d, q)
+}
+
+func (b *concurrentBatch) quad(d Drawable, q *[4]Vertex) {
+	v := quadOrder(q)
+	b.putQuad(d, &v)
+}
+
+// DrawMesh draws a textured triangle mesh. See MeshRenderer.
+//
+func (b *concurrentBatch) DrawMesh(d Drawable, v []Vertex, indices []uint16) {
+	b.DrawMeshLayer(0, d, v, indices)
+}
+
+// DrawMeshLayer draws a textured triangle mesh in the given layer. See
+// LayeredMeshRenderer.
+//
+func (b *concurrentBatch) DrawMeshLayer(layer int, d Drawable, v []Vertex, indices []uint16) {
+	checkMesh(len(v), indices)
+	if len(indices) == 0 {
+		return
+	}
+	if d == nil {
+		d = b.white
+	}
+	if b.layers.sorted {
+		b.layers.pushVertices(primMesh, layer, d, v, indices)
+		return
+	}
+	b.mesh(d, v, indices)
+}
+
+func (b *concurrentBatch) mesh(d Drawable, v []Vertex, indices []uint16) {
+	if meshSlots(len(v)) > b.size {
+		b.triangles(d, expandMesh(v, indices))
+		return
+	}
+	b.indexed(d, v, indices)
+}
+
+// indexed adds the vertices of a triangle mesh to the current buffer. If
+// indices is nil, the triangles are formed by consecutive vertices.
+//
+func (b *concurrentBatch) indexed(d Drawable, v []Vertex, indices []uint16) {
+	slots := meshSlots(len(v))
+	if b.cull.vertices(v) {
+		b.stats.Culled += slots
+		return
+	}
+	first := b.put(d, v, slots)
+	cb := &b.buf[b.cb]
+	if !cb.custom {
+		cb.custom = true
+		cb.indices = appendQuadIndices(cb.indices[:0], 0, first)
+	}
+	cb.indices = appendMeshIndices(cb.indices, uint32(first*4), len(v), indices)
+}
+
+// putQuad adds the vertices of a quad to the current buffer.
+//
+func (b *concurrentBatch) putQuad(d Drawable, q *[4]Vertex) {
+	if b.cull.vertices(q[:]) {
+		b.stats.Culled++
+		return
+	}
+	first := b.put(d, q[:], 1)
+	if cb := &b.buf[b.cb]; cb.custom {
+		cb.indices = appendQuadIndices(cb.indices, first, 1)
+	}
+}
+
+// put adds vertices v to the current buffer, using the given number of quad
+// slots. It returns the first slot used.
+//
+func (b *concurrentBatch) put(d Drawable, v []Vertex, slots int) int {
+	if b.index+slots > b.size {
+		b.flush(flushFull)
+	}
+	ti := b.buf[b.cb].textures.index(d)
+	if ti < 0 {
+		b.flush(flushTexture)
+		ti = b.buf[b.cb].textures.index(d)
+	}
+	cb := &b.buf[b.cb]
+	// Vertices are copied since the worker processes them later. verts is not
+	// reset before the worker is done with this buffer, and slices of it
+	// remain valid if it grows.
+	n := len(cb.verts)
+	cb.verts = append(cb.verts, v...)
+	first := b.index
+	cb.cmds[first] = drawCmd{d: d, tex: uint8(ti), v: cb.verts[n:len(cb.verts):len(cb.verts)]}
+	for i := first + 1; i < first+slots; i++ {
+		cb.cmds[i] = drawCmd{}
+	}
+	b.index += slots
+	return first
+}
+
+// BeginClip starts recording a clip mask. See ClipRenderer.
+//
+func (b *concurrentBatch) BeginClip() {
+	s := b.clip.begin(b.material)
+	b.layers.drain(b)
+	if b.index != 0 {
+		b.flush(flushState)
+	}
+	b.queue(batchOp{kind: opClip, clip: s})
+	b.SetMaterial(b.mask)
+}
+
+// EndClip ends recording a clip mask and puts it in effect. See ClipRenderer.
+//
+func (b *concurrentBatch) EndClip() {
+	s, m := b.clip.end()
+	b.layers.drain(b)
+	if b.index != 0 {
+		b.flush(flushState)
+	}
+	b.queue(batchOp{kind: opClip, clip: s})
+	b.SetMaterial(m)
+}
+
+// PopClip removes the last clip mask. See ClipRenderer.
+//
+func (b *concurrentBatch) PopClip() {
+	remove, s, ok := b.clip.pop()
+	b.layers.drain(b)
+	if b.index != 0 {
+		b.flush(flushState)
+	}
+	if ok {
+		m := b.material
+		b.queue(batchOp{kind: opClip, clip: remove})
+		b.SetMaterial(b.mask)
+		q := b.cull.clipRegion()
+		b.putQuad(b.white, &q)
+		b.SetMaterial(m)
+	}
+	b.queue(batchOp{kind: opClip, clip: s})
+}
+
+// SetPixelSnap enables or disables pixel snapping. See SnapRenderer.
+//
+func (b *concurrentBatch) SetPixelSnap(enabled bool) {
+	b.layers.drain(b)
+	b.snap.setEnabled(enabled)
+}
+
+// SetCulling enables or disables culling. See CullingRenderer.
+//
+func (b *concurrentBatch) SetCulling(enabled bool) {
+	b.cull.disabled = !enabled
+}
+
+// CommandBuffer returns the command buffer identified by order.
+//
+func (b *concurrentBatch) CommandBuffer(order int) *DisplayList {
+	return b.buffers.get(order)
+}
+
 func (b *concurrentBatch) Flush() {
-	b.flush()
+	b.buffers.replay(b)
+	b.flushAll(flushEnd)
+}
+
+// flushAll draws all pending draw calls, including queued draw calls in sorted
+// mode, and waits for the worker to complete.
+//
+func (b *concurrentBatch) flushAll(r flushReason) {
+	b.layers.drain(b)
+	b.flush(r)
 	ab := b.cb ^ 1
-	if b.inFlight > 0 || b.buf[ab].updView {
-		b.flush()
+	if b.inFlight > 0 || len(b.buf[ab].ops) > 0 {
+		b.flush(r)
 	}
 }
 
-func (b *concurrentBatch) flush() {
-	var vertices []float32
+func (b *concurrentBatch) flush(r flushReason) {
+	var vertices []packedVertex
 
 	// get result of last transform
 	if b.inFlight > 0 {
@@ -228,6 +622,7 @@ func (b *concurrentBatch) flush() {
 
 	// send more work before drawing
 	if b.index > 0 {
+		b.stats.flush(r, b.index)
 		b.inFlight++
 		b.drawChan <- b.buf[b.cb].cmds[:b.index]
 	}
@@ -237,36 +632,62 @@ func (b *concurrentBatch) flush() {
 
 	cb := &b.buf[b.cb]
 
-	if cb.updView {
-		v := cb.view
-		gl.Scissor(int32(v.Min.X), int32(v.Min.Y), int32(v.Dx()), int32(v.Dy()))
-		gl.UniformMatrix4fv(b.uniform.cam, 1, gl.GL_FALSE, &cb.proj[0])
-		cb.updView = false
+	for i := range cb.ops {
+		b.apply(&cb.ops[i])
 	}
+	cb.ops = cb.ops[:0]
 
 	if vertices != nil {
-		cb.texture.Bind()
-		gl.BufferSubData(gl.GL_ARRAY_BUFFER, 0, len(vertices)*4, gl.Ptr(&vertices[0]))
-		gl.DrawElements(gl.GL_TRIANGLES, int32(len(vertices)/floatsPerQuad*indicesPerQuad), gl.GL_UNSIGNED_INT, nil)
+		cb.textures.bind()
+		first := b.va.write(vertices)
+		b.stats.DrawCalls++
+		b.stats.Uploaded += len(vertices) * vertexSize
+		if cb.custom {
+			b.stats.Uploaded += b.va.drawIndexed(cb.indices, first, &b.ifmt)
+		} else {
+			b.va.drawQuads(first, len(vertices)/4, &b.ifmt)
+		}
 	}
+	cb.textures.reset()
+	cb.textures.limit = b.material.units
+	cb.verts = cb.verts[:0]
+	cb.indices = cb.indices[:0]
+	cb.custom = false
+}
+
+// SetUploadStrategy flushes the batch and sets the vertex upload strategy.
+//
+func (b *concurrentBatch) SetUploadStrategy(s UploadStrategy) UploadStrategy {
+	b.flushAll(flushState)
+	s = b.va.setUpload(s, b.size)
+	b.va.bind(b.material)
+	return s
 }
 
 func (b *concurrentBatch) End() {
 	b.Flush()
+	b.target.bind(nil)
 }
 
+// Clear clears the clipping region of the current camera. Like state changes,
+// it is queued and does not wait for buffers in flight to be drawn.
+//
 func (b *concurrentBatch) Clear(c color.Color) {
-	// TODO: optimize out the need to do a full flush
-	b.Flush()
+	b.layers.drain(b)
+	if b.index != 0 {
+		b.flush(flushCamera)
+	}
+	op := batchOp{kind: opClear}
 	if c != nil {
-		c := gl.ColorModel.Convert(c).(gl.Color)
-		gl.ClearColor(c.R, c.G, c.B, c.A)
+		op.c, op.setC = gl.ColorModel.Convert(c).(gl.Color), true
 	}
-	gl.Clear(gl.GL_COLOR_BUFFER_BIT)
+	b.queue(op)
 }
 
 func (b *concurrentBatch) Close() {
-	b.program.Delete()
-	gl.DeleteBuffers(1, &b.ebo)
-	gl.DeleteBuffers(1, &b.vbo)
+	close(b.drawChan)
+	b.def.Delete()
+	b.mask.Delete()
+	b.white.Delete()
+	b.va.delete()
 }
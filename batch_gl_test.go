@@ -0,0 +1,51 @@
+// +build egl
+
+package grog
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// TestBatch_firstFlush checks that the first flush of a new batch uploads the
+// vertices of the quads drawn so far. The sync batch used to allocate its
+// vertex slice with a non-zero length and appended to it: its first flush
+// uploaded a full batch of zeroed vertices.
+//
+func TestBatch_firstFlush(t *testing.T) {
+	defer glContext(t)()
+	var (
+		red    = color.RGBA{255, 0, 0, 255}
+		screen = NewScreen(image.Pt(glWidth, glHeight))
+		tex    = solidTexture(4, 4, red)
+	)
+	defer tex.Delete()
+
+	for _, bt := range []struct {
+		name string
+		opts []BatchOption
+	}{
+		{"sync", nil},
+		{"concurrent", []BatchOption{Concurrent(true)}},
+		{"instanced", []BatchOption{Instanced(true)}},
+	} {
+		b, err := NewBatch(bt.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.Begin()
+		b.Camera(screen.View())
+		b.Clear(color.Transparent)
+		b.Draw(tex, Pt(2, 2), Pt(1, 1), 0, nil)
+		b.End()
+		img := screen.Capture(image.Rect(0, 0, 8, 8))
+		if c := img.RGBAAt(3, 3); c != red {
+			t.Errorf("%s: sprite pixel = %v, want %v", bt.name, c, red)
+		}
+		if c := img.RGBAAt(7, 7); c != (color.RGBA{}) {
+			t.Errorf("%s: background pixel = %v, want transparent", bt.name, c)
+		}
+		b.Close()
+	}
+}
@@ -0,0 +1,279 @@
+package grog
+
+import (
+	"fmt"
+	"image/color"
+	"unsafe"
+
+	"github.com/db47h/grog/gl"
+)
+
+// An instance is a sprite record in the instanced batch. Its fields map to
+// the aPosScale, aOffsetRot, aUV and aColor attributes of the vertex shader.
+//
+type instance struct {
+	x, y, w, h      float32 // position, size times scale
+	ox, oy, rot, ti float32 // origin times scale, rotation, texture unit
+	u0, v0, u1, v1  float32 // texture region
+	c               [4]uint8
+}
+
+const instanceSize = 52 // size of an instance
+
+// static check of instanceSize.
+var _ [instanceSize]struct{} = [unsafe.Sizeof(instance{})]struct{}{}
+
+// quadCorners are the corners of a quad in the instanced batch, in the same
+// order as the vertices of a quad in other batches. Drawn as a triangle strip,
+// they form the same triangles (0, 1, 2) and (2, 1, 3).
+//
+var quadCorners = [8]float32{
+	0, 1, // top left
+	1, 1, // top right
+	0, 0, // bottom left
+	1, 0, // bottom right
+}
+
+// An instancedBatch draws sprites in batches using instanced rendering.
+//
+type instancedBatch struct {
+	program gl.Program
+	attr    struct {
+		corner    uint32
+		posScale  uint32
+		offsetRot uint32
+		uv        uint32
+		color     uint32
+	}
+	uniform struct {
+		cam int32
+		tex int32
+	}
+	vao       uint32
+	corners   uint32 // quad corners
+	vbo       uint32 // instance records
+	stream    vertexStream
+	instances []instance
+	index     int // number of instances in the batch
+	size      int // max number of instances
+	textures  textureSet
+	proj      [16]float32
+	blend     BlendMode
+	stats     Stats
+	cull      culler
+	snap      snapper
+	target    targetBinding
+}
+
+func newInstancedBatch(c *batchConfig) (*instancedBatch, error) {
+	var (
+		b     = &instancedBatch{size: c.capacity}
+		units = textureUnits()
+		d     = currentDialect()
+		err   error
+	)
+	in, out := d.qualifiers()
+	b.program, err = compileProgram(
+		[]byte(fmt.Sprintf(vertexShaderInstancedFmt, d.version(), in, out)),
+		fragmentShaderWith(d, units, fragmentVariant{noWrap: true}))
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range []struct {
+		loc  *uint32
+		name string
+	}{
+		{&b.attr.corner, "aCorner"},
+		{&b.attr.posScale, "aPosScale"},
+		{&b.attr.offsetRot, "aOffsetRot"},
+		{&b.attr.uv, "aUV"},
+		{&b.attr.color, "aColor"},
+	} {
+		*a.loc, err = b.program.AttribLocation(a.name)
+		if err != nil {
+			b.program.Delete()
+			return nil, err
+		}
+	}
+	b.uniform.cam = b.program.UniformLocation("uProjection")
+	b.uniform.tex = b.program.UniformLocation("uTextures")
+	b.instances = make([]instance, 0, b.size)
+	b.textures = newTextureSet(units)
+
+	gl.GenVertexArrays(1, &b.vao)
+	gl.BindVertexArray(b.vao)
+
+	gl.GenBuffers(1, &b.corners)
+	gl.BindBuffer(gl.GL_ARRAY_BUFFER, b.corners)
+	gl.BufferData(gl.GL_ARRAY_BUFFER, len(quadCorners)*4, gl.Ptr(&quadCorners[0]), gl.GL_STATIC_DRAW)
+	gl.EnableVertexAttribArray(b.attr.corner)
+	gl.VertexAttribOffset(b.attr.corner, 2, gl.GL_FLOAT, gl.GL_FALSE, 2*4, 0)
+
+	b.vbo, b.stream = newStreamBuffer(UploadRing, instanceSize, b.size)
+	for _, a := range []uint32{b.attr.posScale, b.attr.offsetRot, b.attr.uv, b.attr.color} {
+		gl.EnableVertexAttribArray(a)
+		gl.VertexAttribDivisor(a, 1)
+	}
+	b.setAttribs(0)
+	gl.BindVertexArray(0)
+
+	batchInit()
+
+	return b, nil
+}
+
+// setAttribs sets up the per-instance attributes for instances starting at the
+// given slot of the instance buffer, which must be bound. This replaces the
+// base instance of draw calls, which requires OpenGL 4.2.
+//
+func (b *instancedBatch) setAttribs(first int) {
+	base := first * instanceSize
+	for i, a := range []uint32{b.attr.posScale, b.attr.offsetRot, b.attr.uv, b.attr.color} {
+		if a == b.attr.color {
+			gl.VertexAttribOffset(a, 4, gl.GL_UNSIGNED_BYTE, gl.GL_TRUE, instanceSize, base+i*4*4)
+		} else {
+			gl.VertexAttribOffset(a, 4, gl.GL_FLOAT, gl.GL_FALSE, instanceSize, base+i*4*4)
+		}
+	}
+}
+
+// SetUploadStrategy flushes the batch and sets the upload strategy of sprite
+// records. See UploadRenderer.
+//
+func (b *instancedBatch) SetUploadStrategy(s UploadStrategy) UploadStrategy {
+	b.flush(flushState)
+	b.stream.release()
+	gl.DeleteBuffers(1, &b.vbo)
+	gl.BindVertexArray(b.vao)
+	b.vbo, b.stream = newStreamBuffer(s, instanceSize, b.size)
+	b.setAttribs(0)
+	return b.stream.strategy
+}
+
+func (b *instancedBatch) Begin() {
+	if b.index != 0 {
+		panic("call Flush() before Begin()")
+	}
+	b.stats = Stats{}
+	gl.BindVertexArray(b.vao)
+	gl.BindBuffer(gl.GL_ARRAY_BUFFER, b.vbo)
+	b.program.Use()
+	if b.uniform.tex >= 0 {
+		gl.Uniform1iv(b.uniform.tex, int32(b.textures.limit), &textureUnitIndices[0])
+	}
+	gl.UniformMatrix4fv(b.uniform.cam, 1, gl.GL_FALSE, &b.proj[0])
+	b.blend.apply()
+}
+
+// Stats returns the rendering statistics since the last call to Begin.
+//
+func (b *instancedBatch) Stats() Stats {
+	return b.stats
+}
+
+// Camera sets the camera for world to screen transforms and clipping region.
+//
+func (b *instancedBatch) Camera(c Camera) {
+	b.flush(flushCamera)
+	b.target.bind(cameraTarget(c))
+	b.proj = c.ProjectionMatrix()
+	gl.UniformMatrix4fv(b.uniform.cam, 1, gl.GL_FALSE, &b.proj[0])
+	r := c.GLRect()
+	gl.Scissor(int32(r.Min.X), int32(r.Min.Y), int32(r.Dx()), int32(r.Dy()))
+	b.cull.setCamera(c)
+	b.snap.setCamera(c)
+}
+
+// SetPixelSnap enables or disables pixel snapping. See SnapRenderer.
+//
+func (b *instancedBatch) SetPixelSnap(enabled bool) {
+	b.snap.setEnabled(enabled)
+}
+
+// SetCulling enables or disables culling. See CullingRenderer.
+//
+func (b *instancedBatch) SetCulling(enabled bool) {
+	b.cull.disabled = !enabled
+}
+
+// SetBlendMode sets the blend mode for subsequent draw calls.
+//
+func (b *instancedBatch) SetBlendMode(m BlendMode) {
+	if m == b.blend {
+		return
+	}
+	b.flush(flushState)
+	b.blend = m
+	m.apply()
+}
+
+func (b *instancedBatch) Draw(d Drawable, dp, scale Point, rot float32, c color.Color) {
+	dp, scale = b.snap.sprite(d, dp, scale, rot)
+	if b.cull.sprite(d, dp, scale, rot) {
+		b.stats.Culled++
+		return
+	}
+	if b.index >= b.size {
+		b.flush(flushFull)
+	}
+
+	ti := b.textures.index(d)
+	if ti < 0 {
+		b.flush(flushTexture)
+		ti = b.textures.index(d)
+	}
+
+	o := d.Origin()
+	sz := d.Size()
+	uv := d.UV()
+	b.instances = append(b.instances, instance{
+		dp.X, dp.Y, scale.X * float32(sz.X), scale.Y * float32(sz.Y),
+		float32(o.X) * scale.X, float32(o.Y) * scale.Y, rot, float32(ti),
+		uv[0], uv[1], uv[2], uv[3],
+		packColor(c),
+	})
+	b.index++
+}
+
+func (b *instancedBatch) Flush() {
+	b.flush(flushEnd)
+}
+
+func (b *instancedBatch) flush(r flushReason) {
+	if b.index == 0 {
+		return
+	}
+	b.stats.flush(r, b.index)
+	b.stats.DrawCalls++
+	b.stats.Uploaded += len(b.instances) * instanceSize
+	b.textures.bind()
+
+	first := b.stream.write(unsafe.Pointer(&b.instances[0]), b.index, b.index*instanceSize)
+	b.setAttribs(first)
+	gl.DrawArraysInstanced(gl.GL_TRIANGLE_STRIP, 0, 4, int32(b.index))
+	b.index = 0
+	b.instances = b.instances[:0]
+	b.textures.reset()
+}
+
+func (b *instancedBatch) End() {
+	b.Flush()
+	b.target.bind(nil)
+}
+
+func (b *instancedBatch) Clear(c color.Color) {
+	b.flush(flushCamera)
+	if c != nil {
+		c := gl.ColorModel.Convert(c).(gl.Color)
+		gl.ClearColor(c.R, c.G, c.B, c.A)
+	}
+	gl.Clear(gl.GL_COLOR_BUFFER_BIT)
+}
+
+func (b *instancedBatch) Close() {
+	b.stream.release()
+	b.program.Delete()
+	gl.DeleteBuffers(1, &b.vbo)
+	gl.DeleteBuffers(1, &b.corners)
+	gl.DeleteVertexArrays(1, &b.vao)
+}
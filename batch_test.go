@@ -0,0 +1,48 @@
+package grog
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAppendMeshIndices(t *testing.T) {
+	got := appendMeshIndices([]uint32{0}, 8, 6, nil)
+	if want := []uint32{0, 8, 9, 10, 11, 12, 13}; !reflect.DeepEqual(got, want) {
+		t.Errorf("triangle list: got %v, want %v", got, want)
+	}
+	got = appendMeshIndices(nil, 4, 4, []uint16{0, 1, 3, 3, 1, 2})
+	if want := []uint32{4, 5, 7, 7, 5, 6}; !reflect.DeepEqual(got, want) {
+		t.Errorf("mesh: got %v, want %v", got, want)
+	}
+}
+
+func TestMaxTriangleVertices(t *testing.T) {
+	for _, tc := range []struct{ slots, want int }{{1, 3}, {2, 6}, {3, 12}, {5000, 19998}} {
+		if got := maxTriangleVertices(tc.slots); got != tc.want {
+			t.Errorf("maxTriangleVertices(%d) = %d, want %d", tc.slots, got, tc.want)
+		}
+		if got := maxTriangleVertices(tc.slots); meshSlots(got) > tc.slots {
+			t.Errorf("maxTriangleVertices(%d) = %d does not fit", tc.slots, got)
+		}
+	}
+}
+
+func TestNewBatchConfig(t *testing.T) {
+	def := newBatchConfig()
+	for _, tc := range []struct {
+		opts []BatchOption
+		want batchConfig
+	}{
+		{nil, def},
+		{[]BatchOption{Capacity(0), Workers(0), DispatchThreshold(0)},
+			batchConfig{capacity: def.capacity, workers: 1, threshold: 1}},
+		{[]BatchOption{Capacity(-1), Workers(-3), DispatchThreshold(-10)},
+			batchConfig{capacity: def.capacity, workers: 1, threshold: 1}},
+		{[]BatchOption{Concurrent(true), Instanced(true), Capacity(100), Workers(3), DispatchThreshold(50)},
+			batchConfig{concurrent: true, instanced: true, capacity: 100, workers: 3, threshold: 50}},
+	} {
+		if got := newBatchConfig(tc.opts...); got != tc.want {
+			t.Errorf("got %+v, want %+v", got, tc.want)
+		}
+	}
+}
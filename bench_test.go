@@ -0,0 +1,155 @@
+// +build egl
+
+package grog
+
+import (
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+
+	"github.com/db47h/grog/gl"
+)
+
+// benchSprites returns four 32x32 sprites from a single texture.
+//
+func benchSprites() []Drawable {
+	img := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	colors := [4]color.RGBA{{255, 0, 0, 255}, {0, 255, 0, 255}, {0, 0, 255, 255}, {255, 255, 0, 255}}
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			img.SetRGBA(x, y, colors[y/32*2+x/32])
+		}
+	}
+	t := TextureFromImage(img, Filter(Linear, Nearest))
+	var sp []Drawable
+	for i := 0; i < 4; i++ {
+		x, y := i%2*32, i/2*32
+		sp = append(sp, t.Region(image.Rect(x, y, x+32, y+32), image.Pt(16, 16)))
+	}
+	return sp
+}
+
+// benchFrame draws n sprites like the top view of the demo, same as
+// cmd/bench.
+//
+func benchFrame(b BatchRenderer, v *View, sp []Drawable, n int, rot float32) {
+	s := v.Fb.Size()
+	b.Begin()
+	b.Camera(v)
+	b.Clear(gl.Color{R: .2, G: .2, B: .2, A: 1})
+	rand.Seed(424242)
+	for i := 0; i < n/4; i++ {
+		scale := Pt(1, 1).Mul(rand.Float32() + 0.5)
+		for j := range sp {
+			b.Draw(sp[j], PtI(rand.Intn(s.X*2)-s.X, rand.Intn(s.Y*2)-s.Y), scale, rot*(rand.Float32()+.5), nil)
+		}
+	}
+	b.End()
+}
+
+// benchPixels draws n 1-pixel sprites to the left of the view v: nothing is
+// rasterized, so frame times are bound by vertex uploads and processing.
+//
+func benchPixels(b BatchRenderer, v *View, px Drawable, n int) {
+	b.Begin()
+	b.Camera(v)
+	b.Clear(gl.Color{})
+	rand.Seed(424242)
+	for i := 0; i < n; i++ {
+		b.Draw(px, PtI(-1-rand.Intn(16), rand.Intn(16)), Pt(1, 1), 0, nil)
+	}
+	b.End()
+}
+
+// benchTypes are the batch types used in benchmarks.
+//
+var benchTypes = []struct {
+	name string
+	opts []BatchOption
+}{
+	{"sync", nil},
+	{"concurrent", []BatchOption{Concurrent(true)}},
+	{"instanced", []BatchOption{Instanced(true)}},
+}
+
+// BenchmarkBatch measures the time per frame of 20000 sprites at 1280x720 for
+// each batch type and upload strategy. Run it with:
+//
+//	go test -tags egl -run NONE -bench Batch
+//
+func BenchmarkBatch(b *testing.B) {
+	const spriteCount = 20000
+	benchStrategies(b, func(r BatchRenderer) func(i int) {
+		var (
+			screen = NewScreen(image.Pt(glWidth, glHeight))
+			v      = &View{Fb: screen, Rect: image.Rectangle{Max: screen.Size()}, Scale: 1, OrgPos: OrgCenter}
+			sp     = benchSprites()
+		)
+		return func(i int) {
+			if i < 0 {
+				sp[0].(*Region).Delete()
+				return
+			}
+			benchFrame(r, v, sp, spriteCount, float32(i)/60)
+		}
+	})
+}
+
+// BenchmarkUpload measures the time per frame of 100000 1-pixel sprites that
+// are not rasterized, for each batch type and upload strategy. Unlike
+// BenchmarkBatch, it is not bound by fill rate. Run it with:
+//
+//	go test -tags egl -run NONE -bench Upload
+//
+func BenchmarkUpload(b *testing.B) {
+	const spriteCount = 100000
+	benchStrategies(b, func(r BatchRenderer) func(i int) {
+		rt, err := NewRenderTarget(16, 16, false)
+		if err != nil {
+			b.Fatal(err)
+		}
+		px := solidTexture(1, 1, color.RGBA{255, 255, 255, 255})
+		return func(i int) {
+			if i < 0 {
+				px.Delete()
+				rt.Delete()
+				return
+			}
+			benchPixels(r, rt.View(), px, spriteCount)
+		}
+	})
+}
+
+// benchStrategies runs a sub-benchmark for each batch type and upload
+// strategy. setup returns a function that draws frame i, or releases
+// resources when i is negative.
+//
+func benchStrategies(b *testing.B, setup func(r BatchRenderer) func(i int)) {
+	for _, bt := range benchTypes {
+		for _, s := range []UploadStrategy{UploadSubData, UploadRing, UploadPersistent} {
+			bt, s := bt, s
+			b.Run(bt.name+"/"+s.String(), func(b *testing.B) {
+				defer glContext(b)()
+				r, err := NewBatch(bt.opts...)
+				if err != nil {
+					b.Fatal(err)
+				}
+				defer r.Close()
+				if u := r.(UploadRenderer).SetUploadStrategy(s); u != s {
+					b.Skipf("%s not supported", s)
+				}
+				frame := setup(r)
+				defer frame(-1)
+
+				frame(0) // warm up
+				gl.Finish()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					frame(i)
+				}
+				gl.Finish()
+			})
+		}
+	}
+}
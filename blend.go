@@ -0,0 +1,72 @@
+package grog
+
+import "github.com/db47h/grog/gl"
+
+// BlendMode selects how drawn pixels are combined with the pixels already in
+// the framebuffer.
+//
+// Unless otherwise noted, blend modes expect alpha premultiplied colors, which
+// is what textures created from an image.Image and colors converted by
+// gl.ColorModel are.
+//
+type BlendMode int
+
+// Blend modes.
+//
+const (
+	// BlendPremultipliedAlpha is regular alpha blending. This is the default.
+	BlendPremultipliedAlpha BlendMode = iota
+	// BlendAdditive adds source colors to the destination.
+	BlendAdditive
+	// BlendMultiply multiplies source and destination colors.
+	BlendMultiply
+	// BlendScreen inverts both colors, multiplies them and inverts the result.
+	BlendScreen
+	// BlendReplace replaces destination pixels.
+	BlendReplace
+	// BlendNonPremultipliedAlpha is alpha blending for textures whose colors
+	// are not alpha premultiplied.
+	BlendNonPremultipliedAlpha
+)
+
+func (m BlendMode) String() string {
+	switch m {
+	case BlendPremultipliedAlpha:
+		return "PremultipliedAlpha"
+	case BlendAdditive:
+		return "Additive"
+	case BlendMultiply:
+		return "Multiply"
+	case BlendScreen:
+		return "Screen"
+	case BlendReplace:
+		return "Replace"
+	case BlendNonPremultipliedAlpha:
+		return "NonPremultipliedAlpha"
+	}
+	return "Unknown"
+}
+
+// BlendFunc returns the OpenGL blend factors for the blend mode, as used by
+// gl.BlendFuncSeparate.
+//
+func (m BlendMode) BlendFunc() (srcRGB, dstRGB, srcAlpha, dstAlpha uint32) {
+	switch m {
+	case BlendAdditive:
+		return gl.GL_ONE, gl.GL_ONE, gl.GL_ONE, gl.GL_ONE
+	case BlendMultiply:
+		return gl.GL_DST_COLOR, gl.GL_ONE_MINUS_SRC_ALPHA, gl.GL_DST_ALPHA, gl.GL_ONE_MINUS_SRC_ALPHA
+	case BlendScreen:
+		return gl.GL_ONE, gl.GL_ONE_MINUS_SRC_COLOR, gl.GL_ONE, gl.GL_ONE_MINUS_SRC_ALPHA
+	case BlendReplace:
+		return gl.GL_ONE, gl.GL_ZERO, gl.GL_ONE, gl.GL_ZERO
+	case BlendNonPremultipliedAlpha:
+		return gl.GL_SRC_ALPHA, gl.GL_ONE_MINUS_SRC_ALPHA, gl.GL_ONE, gl.GL_ONE_MINUS_SRC_ALPHA
+	default:
+		return gl.GL_ONE, gl.GL_ONE_MINUS_SRC_ALPHA, gl.GL_ONE, gl.GL_ONE_MINUS_SRC_ALPHA
+	}
+}
+
+func (m BlendMode) apply() {
+	gl.BlendFuncSeparate(m.BlendFunc())
+}
@@ -0,0 +1,132 @@
+package grog
+
+import "github.com/db47h/grog/gl"
+
+// maxClipDepth is the maximum number of nested clip masks with an 8 bits
+// stencil buffer.
+//
+const maxClipDepth = 255
+
+type clipMode int
+
+const (
+	clipTest clipMode = iota // draw calls are clipped by masks in effect
+	clipPush                 // draw calls increment the stencil buffer
+	clipPop                  // draw calls decrement the stencil buffer
+)
+
+// A clipState is the stencil state of a batch. Pixels inside all masks in
+// effect have a stencil value of depth.
+//
+type clipState struct {
+	depth int
+	mode  clipMode
+	clear bool // clear the stencil buffer before applying the state
+}
+
+// apply sets the GL stencil state.
+//
+func (s clipState) apply() {
+	if s.clear {
+		gl.Disable(gl.GL_SCISSOR_TEST)
+		gl.ClearStencil(0)
+		gl.Clear(gl.GL_STENCIL_BUFFER_BIT)
+		gl.Enable(gl.GL_SCISSOR_TEST)
+	}
+	if s.mode == clipTest {
+		gl.ColorMask(gl.GL_TRUE, gl.GL_TRUE, gl.GL_TRUE, gl.GL_TRUE)
+		if s.depth == 0 {
+			gl.Disable(gl.GL_STENCIL_TEST)
+			return
+		}
+	} else {
+		gl.ColorMask(gl.GL_FALSE, gl.GL_FALSE, gl.GL_FALSE, gl.GL_FALSE)
+	}
+	gl.Enable(gl.GL_STENCIL_TEST)
+	gl.StencilFunc(gl.GL_EQUAL, int32(s.depth), 0xff)
+	switch s.mode {
+	case clipTest:
+		gl.StencilOp(gl.GL_KEEP, gl.GL_KEEP, gl.GL_KEEP)
+	case clipPush:
+		gl.StencilOp(gl.GL_KEEP, gl.GL_KEEP, gl.GL_INCR)
+	case clipPop:
+		gl.StencilOp(gl.GL_KEEP, gl.GL_KEEP, gl.GL_DECR)
+	}
+}
+
+// A clipStack keeps track of the clip masks of a batch.
+//
+type clipStack struct {
+	depth     int
+	recording bool      // between BeginClip and EndClip
+	material  *Material // material to restore after EndClip
+}
+
+// begin returns the stencil state to record a new mask.
+//
+func (c *clipStack) begin(m *Material) clipState {
+	if c.recording {
+		panic("BeginClip called while recording a clip mask")
+	}
+	if c.depth >= maxClipDepth {
+		panic("too many nested clip masks")
+	}
+	c.recording = true
+	c.material = m
+	return clipState{depth: c.depth, mode: clipPush, clear: c.depth == 0}
+}
+
+// end returns the stencil state to draw with the new mask in effect, and the
+// material to restore.
+//
+func (c *clipStack) end() (clipState, *Material) {
+	if !c.recording {
+		panic("EndClip called without BeginClip")
+	}
+	c.recording = false
+	c.depth++
+	m := c.material
+	c.material = nil
+	return clipState{depth: c.depth}, m
+}
+
+// pop returns the stencil state to draw with the last mask removed. If masks
+// remain in effect, the last mask must first be removed from the stencil
+// buffer by drawing over the clipping region with the state returned in
+// remove.
+//
+func (c *clipStack) pop() (remove, s clipState, ok bool) {
+	if c.recording {
+		panic("PopClip called while recording a clip mask")
+	}
+	if c.depth == 0 {
+		panic("PopClip called without clip mask")
+	}
+	remove = clipState{depth: c.depth, mode: clipPop}
+	c.depth--
+	return remove, clipState{depth: c.depth}, c.depth > 0
+}
+
+// reset resets the clip stack and returns true if the stencil state must be
+// reset.
+//
+func (c *clipStack) reset() bool {
+	r := c.depth > 0 || c.recording
+	*c = clipStack{}
+	return r
+}
+
+// clipRegion returns an opaque white quad covering the clipping region of the
+// camera, in world coordinates, with vertices in batch order.
+//
+func (k *culler) clipRegion() [4]Vertex {
+	min, max := Point{-1e9, -1e9}, Point{1e9, 1e9}
+	if k.valid {
+		min, max = k.min, k.max
+	}
+	c := gl.Color{R: 1, G: 1, B: 1, A: 1}
+	return [4]Vertex{
+		{Pos: min, Color: c}, {Pos: Point{max.X, min.Y}, Color: c},
+		{Pos: Point{min.X, max.Y}, Color: c}, {Pos: max, Color: c},
+	}
+}
@@ -0,0 +1,123 @@
+// Command bench measures the frame time of batches for each vertex upload
+// strategy, with the sprite workload of the demo's top view.
+//
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"log"
+	"math/rand"
+	"runtime"
+	"time"
+
+	"github.com/db47h/grog"
+	"github.com/db47h/grog/gl"
+)
+
+func init() {
+	// This is needed to arrange that main() runs on main thread.
+	runtime.LockOSThread()
+}
+
+var (
+	spriteCount = flag.Int("n", 20000, "`number` of sprites per frame")
+	frameCount  = flag.Int("f", 300, "`number` of frames per run")
+	core        = flag.Bool("core", false, "request an OpenGL 3.3 core profile context")
+)
+
+const width, height = 1280, 720
+
+func main() {
+	flag.Parse()
+
+	w, err := setupWindow(width, height)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer w.destroy()
+
+	screen := grog.NewScreen(image.Pt(width, height))
+	sp := sprites()
+
+	for _, concurrent := range []bool{false, true} {
+		for _, s := range []grog.UploadStrategy{grog.UploadSubData, grog.UploadRing, grog.UploadPersistent} {
+			b, err := grog.NewBatch(grog.Concurrent(concurrent))
+			if err != nil {
+				log.Fatal(err)
+			}
+			name := "sync"
+			if concurrent {
+				name = "concurrent"
+			}
+			if u := b.(grog.UploadRenderer).SetUploadStrategy(s); u != s {
+				fmt.Printf("%-10s %-10s not supported\n", name, s)
+				b.Close()
+				continue
+			}
+			d, st := run(w, b, screen, sp)
+			fmt.Printf("%-10s %-10s %8.3f ms/frame %8.1f fps %5d draw calls\n",
+				name, s, float64(d)/float64(time.Millisecond), float64(time.Second)/float64(d), st.DrawCalls)
+			b.Close()
+		}
+	}
+}
+
+// run draws frameCount frames and returns the average frame time and the
+// statistics of the last frame.
+//
+func run(w *window, b grog.BatchRenderer, screen *grog.Screen, sp []grog.Drawable) (time.Duration, grog.Stats) {
+	var (
+		s = screen.Size()
+		v = &grog.View{Fb: screen, Rect: image.Rectangle{Max: s}, Scale: 1.0, OrgPos: grog.OrgCenter}
+	)
+
+	// warm up
+	frame(b, v, sp, s, 0)
+	w.swap()
+
+	start := time.Now()
+	for i := 0; i < *frameCount; i++ {
+		frame(b, v, sp, s, float32(i)/60)
+		w.swap()
+	}
+	gl.Finish()
+	return time.Since(start) / time.Duration(*frameCount), b.(grog.StatsRenderer).Stats()
+}
+
+// frame draws the sprites of the demo's top view.
+//
+func frame(b grog.BatchRenderer, v *grog.View, sp []grog.Drawable, s image.Point, rot float32) {
+	b.Begin()
+	b.Camera(v)
+	b.Clear(gl.Color{R: .2, G: .2, B: .2, A: 1})
+	rand.Seed(424242)
+	for i := 0; i < *spriteCount/4; i++ {
+		scale := grog.Pt(1, 1).Mul(rand.Float32() + 0.5)
+		for j := range sp {
+			b.Draw(sp[j], grog.PtI(rand.Intn(s.X*2)-s.X, rand.Intn(s.Y*2)-s.Y), scale, rot*(rand.Float32()+.5), nil)
+		}
+	}
+	b.End()
+}
+
+// sprites returns four 32x32 sprites from a single texture.
+//
+func sprites() []grog.Drawable {
+	img := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	colors := [4]color.RGBA{{255, 0, 0, 255}, {0, 255, 0, 255}, {0, 0, 255, 255}, {255, 255, 0, 255}}
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			img.SetRGBA(x, y, colors[y/32*2+x/32])
+		}
+	}
+	t := grog.TextureFromImage(img, grog.Filter(grog.Linear, grog.Nearest))
+	var sp []grog.Drawable
+	for i := 0; i < 4; i++ {
+		x, y := i%2*32, i/2*32
+		sp = append(sp, t.Region(image.Rect(x, y, x+32, y+32), image.Pt(16, 16)))
+	}
+	return sp
+}
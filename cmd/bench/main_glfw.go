@@ -0,0 +1,62 @@
+package main
+
+import (
+	"log"
+
+	"github.com/db47h/grog/gl"
+	"github.com/go-gl/glfw/v3.2/glfw"
+)
+
+type window glfw.Window
+
+// setupWindow creates a hidden window and makes its GL context current.
+//
+func setupWindow(width, height int) (*window, error) {
+	if err := glfw.Init(); err != nil {
+		return nil, err
+	}
+
+	apiVer := gl.APIVersion()
+	if apiVer.API == gl.OpenGL {
+		glfw.WindowHint(glfw.ClientAPI, glfw.OpenGLAPI)
+	} else {
+		glfw.WindowHint(glfw.ClientAPI, glfw.OpenGLESAPI)
+	}
+	if *core && apiVer.API == gl.OpenGL {
+		apiVer.Major, apiVer.Minor = 3, 3
+	}
+	glfw.WindowHint(glfw.ContextVersionMajor, apiVer.Major)
+	glfw.WindowHint(glfw.ContextVersionMinor, apiVer.Minor)
+	if gl.CoreProfile || *core && apiVer.API == gl.OpenGL {
+		glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
+		glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
+	}
+	glfw.WindowHint(glfw.Visible, glfw.False)
+
+	w, err := glfw.CreateWindow(width, height, "grog bench", nil, nil)
+	if err != nil {
+		glfw.Terminate()
+		return nil, err
+	}
+
+	w.MakeContextCurrent()
+	gl.InitGo(glfw.GetProcAddress)
+	gl.InitExtGo(glfw.GetProcAddress)
+	glfw.SwapInterval(0)
+	gl.Viewport(0, 0, int32(width), int32(height))
+
+	ver := gl.RuntimeVersion()
+	log.Printf("%s %d.%d %s (core profile: %v)", ver.API.String(), ver.Major, ver.Minor, gl.GetGoString(gl.GL_RENDERER), gl.RuntimeCoreProfile())
+
+	return (*window)(w), nil
+}
+
+func (w *window) swap() {
+	(*glfw.Window)(w).SwapBuffers()
+	glfw.PollEvents()
+}
+
+func (w *window) destroy() {
+	(*glfw.Window)(w).Destroy()
+	glfw.Terminate()
+}
@@ -28,6 +28,8 @@ func init() {
 var (
 	vsync       = flag.Int("v", 1, "vsync value for glfw.SwapInterval")
 	spriteCount = flag.Int("n", 20000, "`number` of sprites in the top view")
+	core        = flag.Bool("core", false, "request an OpenGL 3.3 core profile context")
+	instanced   = flag.Bool("instanced", false, "use the instanced batch if available")
 )
 
 func main() {
@@ -58,6 +60,7 @@ type myApp struct {
 	mapView     *grog.View
 	sp          [4]grog.Region
 	tiles       []grog.Region
+	tileMap     *grog.DisplayList
 	rot         float32
 	showTiles   bool
 	fps         debug.Timer
@@ -97,7 +100,7 @@ func (a *myApp) init() (err error) {
 	a.textView = &grog.View{Fb: a.screen, Scale: 1.0}
 	a.mapView = &grog.View{Fb: a.screen, Scale: 1.0}
 
-	b, err := grog.NewBatch(true)
+	b, err := grog.NewBatch(grog.Concurrent(true), grog.Instanced(*instanced))
 	if err != nil {
 		return err
 	}
@@ -159,13 +162,18 @@ func (a *myApp) Draw(ft, lag time.Duration) {
 	rand.Seed(424242)
 	rot := a.rot + float32(lag)/float32(time.Second)
 	if a.showTiles {
-		const worldSz = 320 // 320*320 = 102400 tiles
-		for i := -worldSz / 2; i < worldSz/2; i++ {
-			for j := -worldSz / 2; j < worldSz/2; j++ {
-				// use atlasRegion instead of grog.Region
-				b.Draw((*atlasRegion)(&a.tiles[rand.Intn(len(a.tiles))]), grog.PtI(i*16, j*16), grog.Pt(1, 1), 0.0, nil)
+		// the tile map is static: build it once and replay it every frame
+		if a.tileMap == nil {
+			const worldSz = 320 // 320*320 = 102400 tiles
+			a.tileMap = new(grog.DisplayList)
+			for i := -worldSz / 2; i < worldSz/2; i++ {
+				for j := -worldSz / 2; j < worldSz/2; j++ {
+					// use atlasRegion instead of grog.Region
+					a.tileMap.Draw((*atlasRegion)(&a.tiles[rand.Intn(len(a.tiles))]), grog.PtI(i*16, j*16), grog.Pt(1, 1), 0.0, nil)
+				}
 			}
 		}
+		a.tileMap.Replay(b)
 	} else {
 		for i := 0; i < *spriteCount/4; i++ {
 			scale := grog.Pt(1, 1).Mul(rand.Float32() + 0.5)
@@ -222,6 +230,9 @@ func (a *myApp) Draw(ft, lag time.Duration) {
 	b.Flush()
 	a.ups.Add(time.Since(a.fStart))
 
+	if sr, ok := b.(grog.StatsRenderer); ok {
+		debug.PrintStats(dbg, a.topView, debug.BottomLeft, sr.Stats())
+	}
 	dbg.Printf(a.topView, debug.TopRight, "%.0f fps / %.0f ups", a.fps.AveragePerSecond(), a.ups.AveragePerSecond())
 
 	b.End()
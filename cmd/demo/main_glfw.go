@@ -39,10 +39,16 @@ func (a *myApp) setupWindow() (nw *nativeWin, err error) {
 	} else {
 		glfw.WindowHint(glfw.ClientAPI, glfw.OpenGLESAPI)
 	}
+	if *core && apiVer.API == gl.OpenGL {
+		// The package API is OpenGL 2.1, but batches switch to VAOs and GLSL
+		// 1.50+ shaders when running in a core profile context.
+		apiVer.Major, apiVer.Minor = 3, 3
+	}
 	glfw.WindowHint(glfw.ContextVersionMajor, apiVer.Major)
 	glfw.WindowHint(glfw.ContextVersionMinor, apiVer.Minor)
-	if gl.CoreProfile {
+	if gl.CoreProfile || *core && apiVer.API == gl.OpenGL {
 		glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
+		glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
 	}
 	glfw.WindowHint(glfw.Samples, 4)
 
@@ -60,6 +66,7 @@ func (a *myApp) setupWindow() (nw *nativeWin, err error) {
 	// Init OpenGL
 	window.MakeContextCurrent()
 	gl.InitGo(glfw.GetProcAddress)
+	gl.InitExtGo(glfw.GetProcAddress)
 
 	glfw.SwapInterval(*vsync)
 	fbSz := image.Pt(window.GetFramebufferSize())
@@ -68,7 +75,7 @@ func (a *myApp) setupWindow() (nw *nativeWin, err error) {
 
 	log.Print("GLFW ", glfw.GetVersionString())
 	ver := gl.RuntimeVersion()
-	log.Printf("%s %d.%d %s", ver.API.String(), ver.Major, ver.Minor, gl.GetGoString(gl.GL_VENDOR))
+	log.Printf("%s %d.%d %s (core profile: %v)", ver.API.String(), ver.Major, ver.Minor, gl.GetGoString(gl.GL_VENDOR), gl.RuntimeCoreProfile())
 
 	// setup callbacks
 	window.SetScrollCallback(func(w *glfw.Window, xoff float64, yoff float64) {
@@ -0,0 +1,51 @@
+package grog
+
+import (
+	"sort"
+	"sync"
+)
+
+// A cmdBuffer is a command buffer of a ParallelRenderer.
+//
+type cmdBuffer struct {
+	order int
+	l     *DisplayList
+}
+
+// cmdBuffers holds the command buffers of a ParallelRenderer, sorted by order.
+//
+type cmdBuffers struct {
+	mu   sync.Mutex
+	bufs []cmdBuffer
+}
+
+// get returns the command buffer with the given order, creating it if
+// necessary. It is safe for concurrent use.
+//
+func (c *cmdBuffers) get(order int) *DisplayList {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i := sort.Search(len(c.bufs), func(i int) bool { return c.bufs[i].order >= order })
+	if i < len(c.bufs) && c.bufs[i].order == order {
+		return c.bufs[i].l
+	}
+	l := new(DisplayList)
+	c.bufs = append(c.bufs, cmdBuffer{})
+	copy(c.bufs[i+1:], c.bufs[i:])
+	c.bufs[i] = cmdBuffer{order, l}
+	return l
+}
+
+// replay replays the commands of all buffers into r in ascending order, then
+// resets the buffers.
+//
+func (c *cmdBuffers) replay(r Renderer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, b := range c.bufs {
+		if b.l.Len() > 0 {
+			b.l.Replay(r)
+			b.l.Reset()
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package grog
+
+import (
+	"image"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCmdBuffers(t *testing.T) {
+	var (
+		c   cmdBuffers
+		got recorder
+		d   = &testDrawable{1, image.Pt(1, 1)}
+	)
+	for _, order := range []int{3, -1, 7, 0} {
+		c.get(order).Draw(d, PtI(order, 0), Pt(1, 1), 0, nil)
+	}
+	if l := c.get(3); l.Len() != 1 {
+		t.Fatalf("get(3) returned a new buffer")
+	}
+	c.replay(&got)
+	want := []string{
+		"Draw tex1 (-1.00,0.00) (1.00,1.00) 0 <nil>",
+		"Draw tex1 (0.00,0.00) (1.00,1.00) 0 <nil>",
+		"Draw tex1 (3.00,0.00) (1.00,1.00) 0 <nil>",
+		"Draw tex1 (7.00,0.00) (1.00,1.00) 0 <nil>",
+	}
+	if !reflect.DeepEqual(got.log, want) {
+		t.Fatalf("replay:\ngot  %q\nwant %q", got.log, want)
+	}
+
+	// buffers are reset after replay
+	got = recorder{}
+	c.replay(&got)
+	if len(got.log) != 0 {
+		t.Fatalf("second replay: got %q", got.log)
+	}
+
+	// all recorded calls are replayed
+	var want2 recorder
+	record(&want2)
+	record(c.get(1))
+	c.replay(&got)
+	for i := range got.log {
+		if strings.HasPrefix(got.log[i], "SetMaterial") {
+			got.log[i], want2.log[i] = "SetMaterial", "SetMaterial"
+		}
+	}
+	if !reflect.DeepEqual(got.log, want2.log) {
+		t.Fatalf("replay:\ngot  %q\nwant %q", got.log, want2.log)
+	}
+}
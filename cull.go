@@ -0,0 +1,108 @@
+package grog
+
+import "math"
+
+// A culler rejects draw calls entirely outside of the clipping region of the
+// current camera.
+//
+type culler struct {
+	disabled bool
+	valid    bool  // false until a camera is set
+	min, max Point // bounding box of the clipping region, in world coordinates
+}
+
+// setCamera computes the world space bounding box of the clipping region of c.
+// For cameras with a known frame buffer (see cameraFb), this is their GL
+// rectangle. For other cameras, it is the whole frame buffer, which is
+// conservative but always correct.
+//
+func (k *culler) setCamera(c Camera) {
+	var (
+		p       = c.ProjectionMatrix()
+		x0, y0  = float32(-1), float32(-1)
+		x1, y1  = float32(1), float32(1)
+		a, b    = p[0], p[4] // x = a*wx + b*wy + tx
+		cc, d   = p[1], p[5] // y = cc*wx + d*wy + ty
+		tx, ty  = p[12], p[13]
+		det     = a*d - b*cc
+		corners [4]Point
+	)
+	if fb := cameraFb(c); fb != nil {
+		sz := fb.Size()
+		r := c.GLRect()
+		sw, sh := float32(sz.X), float32(sz.Y)
+		x0, y0 = 2*float32(r.Min.X)/sw-1, 2*float32(r.Min.Y)/sh-1
+		x1, y1 = 2*float32(r.Max.X)/sw-1, 2*float32(r.Max.Y)/sh-1
+	}
+	if det == 0 {
+		k.valid = false
+		return
+	}
+	corners = [4]Point{{x0, y0}, {x1, y0}, {x0, y1}, {x1, y1}}
+	for i, pt := range corners {
+		x, y := pt.X-tx, pt.Y-ty
+		w := Point{(d*x - b*y) / det, (a*y - cc*x) / det}
+		if i == 0 {
+			k.min, k.max = w, w
+			continue
+		}
+		k.min.X, k.max.X = minf(k.min.X, w.X), maxf(k.max.X, w.X)
+		k.min.Y, k.max.Y = minf(k.min.Y, w.Y), maxf(k.max.Y, w.Y)
+	}
+	k.valid = true
+}
+
+// outside returns true if the box (x0, y0)-(x1, y1) is entirely outside the
+// clipping region.
+//
+func (k *culler) outside(x0, y0, x1, y1 float32) bool {
+	return x1 < k.min.X || x0 > k.max.X || y1 < k.min.Y || y0 > k.max.Y
+}
+
+// sprite returns true if a sprite drawn with the given parameters must be
+// culled. Rotated sprites are tested with their bounding circle.
+//
+func (k *culler) sprite(d Drawable, dp, scale Point, rot float32) bool {
+	if k.disabled || !k.valid {
+		return false
+	}
+	o := d.Origin()
+	sz := d.Size()
+	x0, y0 := -float32(o.X)*scale.X, -float32(o.Y)*scale.Y
+	x1, y1 := x0+float32(sz.X)*scale.X, y0+float32(sz.Y)*scale.Y
+	if rot != 0 {
+		rx, ry := maxf(x0*x0, x1*x1), maxf(y0*y0, y1*y1)
+		r := float32(math.Sqrt(float64(rx + ry)))
+		return k.outside(dp.X-r, dp.Y-r, dp.X+r, dp.Y+r)
+	}
+	return k.outside(dp.X+minf(x0, x1), dp.Y+minf(y0, y1), dp.X+maxf(x0, x1), dp.Y+maxf(y0, y1))
+}
+
+// vertices returns true if the bounding box of v is entirely outside the
+// clipping region.
+//
+func (k *culler) vertices(v []Vertex) bool {
+	if k.disabled || !k.valid || len(v) == 0 {
+		return false
+	}
+	min, max := v[0].Pos, v[0].Pos
+	for _, v := range v[1:] {
+		min.X, max.X = minf(min.X, v.Pos.X), maxf(max.X, v.Pos.X)
+		min.Y, max.Y = minf(min.Y, v.Pos.Y), maxf(max.Y, v.Pos.Y)
+	}
+	return k.outside(min.X, min.Y, max.X, max.Y)
+}
+
+func minf(a, b float32) float32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxf(a, b float32) float32 {
+	if a > b {
+		return a
+	}
+	return b
+}
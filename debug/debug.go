@@ -79,3 +79,12 @@ func (p *printer) Print(v *grog.View, pos Pos, s string) {
 	p.r.Clear(color.RGBA{A: 255})
 	p.td.DrawString(p.r, s, grog.PtPt(pt), grog.Pt(1, 1), color.White)
 }
+
+// PrintStats prints batch statistics on a single line. Since printing draws
+// to the same renderer, s should be retrieved before printing.
+//
+func PrintStats(p Printer, v *grog.View, pos Pos, s grog.Stats) {
+	p.Printf(v, pos, "%d draw calls, %d quads, %d culled, %.1f KiB | flushes: %d full, %d texture, %d camera, %d state, %d end",
+		s.DrawCalls, s.Quads, s.Culled, float64(s.Uploaded)/1024,
+		s.FullFlushes, s.TextureFlushes, s.CameraFlushes, s.StateFlushes, s.EndFlushes)
+}
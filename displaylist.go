@@ -0,0 +1,447 @@
+package grog
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/db47h/grog/gl"
+)
+
+// CommandType identifies the Renderer method recorded in a Command.
+//
+type CommandType int
+
+// Command types.
+//
+const (
+	CmdDraw CommandType = iota
+	CmdCamera
+	CmdClear
+	CmdDrawLayer
+	CmdDrawTriangles
+	CmdDrawQuad
+	CmdDrawMesh
+	CmdSetBlendMode
+	CmdSetMaterial
+	CmdSetUniform
+	CmdSetSorted
+	CmdBeginClip
+	CmdEndClip
+	CmdPopClip
+	CmdDrawTrianglesLayer
+	CmdDrawQuadLayer
+	CmdDrawMeshLayer
+)
+
+var cmdNames = [...]string{
+	CmdDraw:          "Draw",
+	CmdCamera:        "Camera",
+	CmdClear:         "Clear",
+	CmdDrawLayer:     "DrawLayer",
+	CmdDrawTriangles: "DrawTriangles",
+	CmdDrawQuad:      "DrawQuad",
+	CmdDrawMesh:      "DrawMesh",
+	CmdSetBlendMode:  "SetBlendMode",
+	CmdSetMaterial:   "SetMaterial",
+	CmdSetUniform:    "SetUniform",
+	CmdSetSorted:     "SetSorted",
+	CmdBeginClip:     "BeginClip",
+	CmdEndClip:       "EndClip",
+	CmdPopClip:       "PopClip",
+
+	CmdDrawTrianglesLayer: "DrawTrianglesLayer",
+	CmdDrawQuadLayer:      "DrawQuadLayer",
+	CmdDrawMeshLayer:      "DrawMeshLayer",
+}
+
+func (t CommandType) String() string {
+	if t >= 0 && int(t) < len(cmdNames) {
+		return cmdNames[t]
+	}
+	return "Unknown"
+}
+
+// A Command is a Renderer call recorded in a DisplayList. Only the fields
+// relevant to the command Type are set.
+//
+type Command struct {
+	Type CommandType
+
+	// Draw arguments
+	Drawable Drawable
+	Pos      Point
+	Scale    Point
+	Rot      float32
+
+	// Layer of DrawLayer, DrawTrianglesLayer, DrawQuadLayer and
+	// DrawMeshLayer
+	Layer int
+
+	// Draw, DrawLayer and Clear color
+	Color color.Color
+
+	// Camera argument
+	Camera *CameraState
+
+	// DrawTriangles, DrawQuad and DrawMesh arguments, and their layered
+	// variants. Drawable is nil for untextured triangles. DrawQuad has 4
+	// vertices.
+	Vertices []Vertex
+	Indices  []uint16
+
+	// SetBlendMode argument
+	BlendMode BlendMode
+
+	// SetMaterial argument
+	Material *Material
+
+	// SetUniform arguments
+	Uniform string
+	Values  []float32
+
+	// SetSorted argument
+	Sorted bool
+}
+
+// CameraState is a snapshot of a Camera's projection matrix and clipping
+// rectangle. It implements Camera.
+//
+// Fb is the frame buffer the camera renders into, if known: the parent
+// FrameBuffer of a *View, nil for other cameras. Batches use it like the
+// parent of a View: a CameraState recorded from a View of a RenderTarget
+// renders into the RenderTarget, and culling and pixel snapping use the size
+// of Fb.
+//
+type CameraState struct {
+	Projection [16]float32
+	Rect       image.Rectangle
+	Fb         FrameBuffer
+}
+
+// ProjectionMatrix implements Camera.
+//
+func (c *CameraState) ProjectionMatrix() [16]float32 {
+	return c.Projection
+}
+
+// GLRect implements Camera.
+//
+func (c *CameraState) GLRect() image.Rectangle {
+	return c.Rect
+}
+
+// A DisplayList is a Renderer that records draw calls and state changes into
+// a command list that can be replayed into any other Renderer. This is
+// typically used to build static scenery once and draw it many times.
+//
+// Besides the Renderer methods, a DisplayList records the methods of
+// LayeredMeshRenderer, MaterialRenderer, ClipRenderer and SetBlendMode, so
+// that shapes, meshes, layers and material or blend mode changes can be
+// recorded too. Begin, Flush, End, Close and the renderer settings of
+// StatsRenderer, CullingRenderer, SnapRenderer and UploadRenderer are not
+// recorded and must be called on the target renderer directly.
+//
+// Cameras are recorded as a snapshot of their state when Camera is called:
+// subsequent changes to the Camera do not affect the recorded commands.
+// Vertices, indices and uniform values are copied. Drawables, colors and
+// materials are recorded as is.
+//
+//	// build once
+//	dl := new(grog.DisplayList)
+//	for i := range tiles {
+//		dl.Draw(&tiles[i], tilePos[i], grog.Pt(1, 1), 0, nil)
+//	}
+//
+//	// draw loop
+//	b.Begin()
+//	b.Camera(view)
+//	dl.Replay(b)
+//	b.End()
+//
+type DisplayList struct {
+	cmds []Command
+	v    []Vertex  // vertex storage
+	i    []uint16  // index storage
+	f    []float32 // uniform value storage
+}
+
+func (l *DisplayList) push(c Command) {
+	l.cmds = append(l.cmds, c)
+}
+
+// vertices returns a copy of v in the vertex storage of the list.
+//
+func (l *DisplayList) vertices(v []Vertex) []Vertex {
+	n := len(l.v)
+	l.v = append(l.v, v...)
+	return l.v[n:len(l.v):len(l.v)]
+}
+
+// indices returns a copy of indices in the index storage of the list.
+//
+func (l *DisplayList) indices(indices []uint16) []uint16 {
+	n := len(l.i)
+	l.i = append(l.i, indices...)
+	return l.i[n:len(l.i):len(l.i)]
+}
+
+// Draw records a Draw call.
+//
+func (l *DisplayList) Draw(d Drawable, dp, scale Point, rot float32, c color.Color) {
+	l.push(Command{Type: CmdDraw, Drawable: d, Pos: dp, Scale: scale, Rot: rot, Color: c})
+}
+
+// DrawLayer records a DrawLayer call. See LayeredRenderer.
+//
+func (l *DisplayList) DrawLayer(layer int, d Drawable, dp, scale Point, rot float32, c color.Color) {
+	l.push(Command{Type: CmdDrawLayer, Layer: layer, Drawable: d, Pos: dp, Scale: scale, Rot: rot, Color: c})
+}
+
+// Camera records a Camera call.
+//
+func (l *DisplayList) Camera(c Camera) {
+	l.push(Command{Type: CmdCamera, Camera: &CameraState{Projection: c.ProjectionMatrix(), Rect: c.GLRect(), Fb: cameraFb(c)}})
+}
+
+// Clear records a Clear call.
+//
+func (l *DisplayList) Clear(c color.Color) {
+	l.push(Command{Type: CmdClear, Color: c})
+}
+
+// DrawTriangles records a DrawTriangles call. See ShapeRenderer.
+//
+func (l *DisplayList) DrawTriangles(d Drawable, v []Vertex) {
+	l.push(Command{Type: CmdDrawTriangles, Drawable: d, Vertices: l.vertices(v)})
+}
+
+// DrawQuad records a DrawQuad call. See MeshRenderer.
+//
+func (l *DisplayList) DrawQuad(d Drawable, q *[4]Vertex) {
+	l.push(Command{Type: CmdDrawQuad, Drawable: d, Vertices: l.vertices(q[:])})
+}
+
+// DrawMesh records a DrawMesh call. See MeshRenderer.
+//
+func (l *DisplayList) DrawMesh(d Drawable, v []Vertex, indices []uint16) {
+	checkMesh(len(v), indices)
+	l.push(Command{Type: CmdDrawMesh, Drawable: d, Vertices: l.vertices(v), Indices: l.indices(indices)})
+}
+
+// DrawTrianglesLayer records a DrawTrianglesLayer call. See
+// LayeredMeshRenderer.
+//
+func (l *DisplayList) DrawTrianglesLayer(layer int, d Drawable, v []Vertex) {
+	l.push(Command{Type: CmdDrawTrianglesLayer, Layer: layer, Drawable: d, Vertices: l.vertices(v)})
+}
+
+// DrawQuadLayer records a DrawQuadLayer call. See LayeredMeshRenderer.
+//
+func (l *DisplayList) DrawQuadLayer(layer int, d Drawable, q *[4]Vertex) {
+	l.push(Command{Type: CmdDrawQuadLayer, Layer: layer, Drawable: d, Vertices: l.vertices(q[:])})
+}
+
+// DrawMeshLayer records a DrawMeshLayer call. See LayeredMeshRenderer.
+//
+func (l *DisplayList) DrawMeshLayer(layer int, d Drawable, v []Vertex, indices []uint16) {
+	checkMesh(len(v), indices)
+	l.push(Command{Type: CmdDrawMeshLayer, Layer: layer, Drawable: d, Vertices: l.vertices(v), Indices: l.indices(indices)})
+}
+
+// SetBlendMode records a SetBlendMode call.
+//
+func (l *DisplayList) SetBlendMode(m BlendMode) {
+	l.push(Command{Type: CmdSetBlendMode, BlendMode: m})
+}
+
+// SetMaterial records a SetMaterial call. See MaterialRenderer.
+//
+func (l *DisplayList) SetMaterial(m *Material) {
+	l.push(Command{Type: CmdSetMaterial, Material: m})
+}
+
+// SetUniform records a SetUniform call. See MaterialRenderer.
+//
+func (l *DisplayList) SetUniform(name string, v ...float32) {
+	gl.CheckUniform(v)
+	n := len(l.f)
+	l.f = append(l.f, v...)
+	l.push(Command{Type: CmdSetUniform, Uniform: name, Values: l.f[n:len(l.f):len(l.f)]})
+}
+
+// SetSorted records a SetSorted call. See LayeredRenderer.
+//
+func (l *DisplayList) SetSorted(sorted bool) {
+	l.push(Command{Type: CmdSetSorted, Sorted: sorted})
+}
+
+// BeginClip records a BeginClip call. See ClipRenderer.
+//
+func (l *DisplayList) BeginClip() {
+	l.push(Command{Type: CmdBeginClip})
+}
+
+// EndClip records an EndClip call. See ClipRenderer.
+//
+func (l *DisplayList) EndClip() {
+	l.push(Command{Type: CmdEndClip})
+}
+
+// PopClip records a PopClip call. See ClipRenderer.
+//
+func (l *DisplayList) PopClip() {
+	l.push(Command{Type: CmdPopClip})
+}
+
+// Replay replays all recorded commands into r, in order.
+//
+// Commands for methods that r does not implement are handled as follows:
+// DrawLayer calls Draw, the layered variants of DrawTriangles, DrawQuad and
+// DrawMesh call the non-layered method, and all other commands are skipped.
+// In particular, a DisplayList implements all recorded methods, so lists can
+// be replayed into other lists.
+//
+func (l *DisplayList) Replay(r Renderer) {
+	for i := range l.cmds {
+		l.cmds[i].replay(r)
+	}
+}
+
+func (c *Command) replay(r Renderer) {
+	switch c.Type {
+	case CmdDraw:
+		r.Draw(c.Drawable, c.Pos, c.Scale, c.Rot, c.Color)
+	case CmdCamera:
+		r.Camera(c.Camera)
+	case CmdClear:
+		r.Clear(c.Color)
+	case CmdDrawLayer:
+		if lr, ok := r.(layerDrawer); ok {
+			lr.DrawLayer(c.Layer, c.Drawable, c.Pos, c.Scale, c.Rot, c.Color)
+		} else {
+			r.Draw(c.Drawable, c.Pos, c.Scale, c.Rot, c.Color)
+		}
+	case CmdDrawTriangles:
+		if r, ok := r.(triangleDrawer); ok {
+			r.DrawTriangles(c.Drawable, c.Vertices)
+		}
+	case CmdDrawQuad:
+		if r, ok := r.(meshDrawer); ok {
+			var q [4]Vertex
+			copy(q[:], c.Vertices)
+			r.DrawQuad(c.Drawable, &q)
+		}
+	case CmdDrawMesh:
+		if r, ok := r.(meshDrawer); ok {
+			r.DrawMesh(c.Drawable, c.Vertices, c.Indices)
+		}
+	case CmdDrawTrianglesLayer:
+		if lr, ok := r.(layeredMeshDrawer); ok {
+			lr.DrawTrianglesLayer(c.Layer, c.Drawable, c.Vertices)
+		} else if r, ok := r.(triangleDrawer); ok {
+			r.DrawTriangles(c.Drawable, c.Vertices)
+		}
+	case CmdDrawQuadLayer:
+		var q [4]Vertex
+		copy(q[:], c.Vertices)
+		if lr, ok := r.(layeredMeshDrawer); ok {
+			lr.DrawQuadLayer(c.Layer, c.Drawable, &q)
+		} else if r, ok := r.(meshDrawer); ok {
+			r.DrawQuad(c.Drawable, &q)
+		}
+	case CmdDrawMeshLayer:
+		if lr, ok := r.(layeredMeshDrawer); ok {
+			lr.DrawMeshLayer(c.Layer, c.Drawable, c.Vertices, c.Indices)
+		} else if r, ok := r.(meshDrawer); ok {
+			r.DrawMesh(c.Drawable, c.Vertices, c.Indices)
+		}
+	case CmdSetBlendMode:
+		if r, ok := r.(blendSetter); ok {
+			r.SetBlendMode(c.BlendMode)
+		}
+	case CmdSetMaterial:
+		if r, ok := r.(materialSetter); ok {
+			r.SetMaterial(c.Material)
+		}
+	case CmdSetUniform:
+		if r, ok := r.(materialSetter); ok {
+			r.SetUniform(c.Uniform, c.Values...)
+		}
+	case CmdSetSorted:
+		if r, ok := r.(layerDrawer); ok {
+			r.SetSorted(c.Sorted)
+		}
+	case CmdBeginClip:
+		if r, ok := r.(clipper); ok {
+			r.BeginClip()
+		}
+	case CmdEndClip:
+		if r, ok := r.(clipper); ok {
+			r.EndClip()
+		}
+	case CmdPopClip:
+		if r, ok := r.(clipper); ok {
+			r.PopClip()
+		}
+	}
+}
+
+// Subsets of the optional Renderer interfaces, without BatchRenderer, used by
+// Replay.
+//
+type (
+	layerDrawer interface {
+		SetSorted(sorted bool)
+		DrawLayer(layer int, d Drawable, dp, scale Point, rot float32, c color.Color)
+	}
+	triangleDrawer interface {
+		DrawTriangles(d Drawable, v []Vertex)
+	}
+	meshDrawer interface {
+		DrawQuad(d Drawable, q *[4]Vertex)
+		DrawMesh(d Drawable, v []Vertex, indices []uint16)
+	}
+	layeredMeshDrawer interface {
+		DrawTrianglesLayer(layer int, d Drawable, v []Vertex)
+		DrawQuadLayer(layer int, d Drawable, q *[4]Vertex)
+		DrawMeshLayer(layer int, d Drawable, v []Vertex, indices []uint16)
+	}
+	blendSetter interface {
+		SetBlendMode(BlendMode)
+	}
+	materialSetter interface {
+		SetMaterial(m *Material)
+		SetUniform(name string, v ...float32)
+	}
+	clipper interface {
+		BeginClip()
+		EndClip()
+		PopClip()
+	}
+)
+
+// Commands returns the recorded commands. The returned slice is only valid
+// until the next call to any other DisplayList method and must not be
+// modified.
+//
+func (l *DisplayList) Commands() []Command {
+	return l.cmds
+}
+
+// Len returns the number of recorded commands.
+//
+func (l *DisplayList) Len() int {
+	return len(l.cmds)
+}
+
+// Reset clears the command list. The underlying storage is reused.
+//
+func (l *DisplayList) Reset() {
+	for i := range l.cmds {
+		l.cmds[i] = Command{}
+	}
+	l.cmds = l.cmds[:0]
+	l.v = l.v[:0]
+	l.i = l.i[:0]
+	l.f = l.f[:0]
+}
@@ -0,0 +1,291 @@
+package grog
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"reflect"
+	"testing"
+)
+
+// A basicRecorder is a Renderer that logs calls to the Renderer methods.
+//
+type basicRecorder struct {
+	log []string
+}
+
+func (r *basicRecorder) logf(format string, args ...interface{}) {
+	r.log = append(r.log, fmt.Sprintf(format, args...))
+}
+
+func (r *basicRecorder) Draw(d Drawable, dp, scale Point, rot float32, c color.Color) {
+	r.logf("Draw %v %v %v %v %v", d, dp, scale, rot, c)
+}
+
+func (r *basicRecorder) Camera(c Camera) {
+	r.logf("Camera %v %v", c.ProjectionMatrix(), c.GLRect())
+}
+
+func (r *basicRecorder) Clear(c color.Color) {
+	r.logf("Clear %v", c)
+}
+
+// A recorder logs calls to all the methods recorded by a DisplayList.
+//
+type recorder struct {
+	basicRecorder
+}
+
+func (r *recorder) DrawLayer(layer int, d Drawable, dp, scale Point, rot float32, c color.Color) {
+	r.logf("DrawLayer %d %v %v %v %v %v", layer, d, dp, scale, rot, c)
+}
+
+func (r *recorder) DrawTriangles(d Drawable, v []Vertex) {
+	r.logf("DrawTriangles %v %v", d, v)
+}
+
+func (r *recorder) DrawQuad(d Drawable, q *[4]Vertex) {
+	r.logf("DrawQuad %v %v", d, *q)
+}
+
+func (r *recorder) DrawMesh(d Drawable, v []Vertex, indices []uint16) {
+	r.logf("DrawMesh %v %v %v", d, v, indices)
+}
+
+func (r *recorder) DrawTrianglesLayer(layer int, d Drawable, v []Vertex) {
+	r.logf("DrawTrianglesLayer %d %v %v", layer, d, v)
+}
+
+func (r *recorder) DrawQuadLayer(layer int, d Drawable, q *[4]Vertex) {
+	r.logf("DrawQuadLayer %d %v %v", layer, d, *q)
+}
+
+func (r *recorder) DrawMeshLayer(layer int, d Drawable, v []Vertex, indices []uint16) {
+	r.logf("DrawMeshLayer %d %v %v %v", layer, d, v, indices)
+}
+
+func (r *recorder) SetBlendMode(m BlendMode) { r.logf("SetBlendMode %v", m) }
+func (r *recorder) SetMaterial(m *Material)  { r.logf("SetMaterial %p", m) }
+func (r *recorder) SetSorted(sorted bool)    { r.logf("SetSorted %v", sorted) }
+func (r *recorder) BeginClip()               { r.logf("BeginClip") }
+func (r *recorder) EndClip()                 { r.logf("EndClip") }
+func (r *recorder) PopClip()                 { r.logf("PopClip") }
+
+func (r *recorder) SetUniform(name string, v ...float32) {
+	r.logf("SetUniform %s %v", name, v)
+}
+
+// A testDrawable is a Drawable that does not need an OpenGL context.
+//
+type testDrawable struct {
+	id   uint32
+	size image.Point
+}
+
+func (d *testDrawable) Bind()               {}
+func (d *testDrawable) NativeID() uint32    { return d.id }
+func (d *testDrawable) Origin() image.Point { return image.ZP }
+func (d *testDrawable) Size() image.Point   { return d.size }
+func (d *testDrawable) UV() [4]float32      { return [4]float32{0, 1, 1, 0} }
+func (d *testDrawable) String() string      { return fmt.Sprintf("tex%d", d.id) }
+
+// record records one call of each recorded method into r and returns the
+// material used.
+//
+func record(r interface {
+	Renderer
+	layerDrawer
+	triangleDrawer
+	meshDrawer
+	layeredMeshDrawer
+	blendSetter
+	materialSetter
+	clipper
+}) *Material {
+	var (
+		d = &testDrawable{1, image.Pt(16, 16)}
+		m = new(Material)
+		v = []Vertex{{Pos: Pt(0, 0)}, {Pos: Pt(1, 0)}, {Pos: Pt(1, 1)}, {Pos: Pt(0, 1)}}
+		q = [4]Vertex{v[0], v[1], v[2], v[3]}
+	)
+	r.Camera(NewScreen(image.Pt(320, 200)).View())
+	r.Clear(color.Black)
+	r.SetSorted(true)
+	r.Draw(d, Pt(1, 2), Pt(1, 1), 0, nil)
+	r.DrawLayer(3, d, Pt(3, 4), Pt(2, 2), 1, color.White)
+	r.DrawTriangles(nil, v[:3])
+	r.DrawQuad(d, &q)
+	r.DrawMesh(d, v, []uint16{0, 1, 2, 2, 3, 0})
+	r.DrawTrianglesLayer(-1, d, v[1:])
+	r.DrawQuadLayer(2, nil, &q)
+	r.DrawMeshLayer(1, nil, v, []uint16{3, 2, 1})
+	r.SetBlendMode(BlendAdditive)
+	r.SetMaterial(m)
+	r.SetUniform("uTime", 1, 2)
+	r.BeginClip()
+	r.EndClip()
+	r.PopClip()
+	r.SetSorted(false)
+	return m
+}
+
+func TestDisplayList_Replay(t *testing.T) {
+	var (
+		want, got recorder
+		l         DisplayList
+	)
+	record(&want)
+	record(&l)
+	if l.Len() != len(want.log) {
+		t.Fatalf("Len() = %d, want %d", l.Len(), len(want.log))
+	}
+	l.Replay(&got)
+	// materials are different pointers, but were logged in the same position.
+	for i := range want.log {
+		if l.Commands()[i].Type == CmdSetMaterial {
+			got.log[i], want.log[i] = "SetMaterial", "SetMaterial"
+		}
+	}
+	if !reflect.DeepEqual(got.log, want.log) {
+		t.Fatalf("Replay:\ngot  %q\nwant %q", got.log, want.log)
+	}
+
+	// replay twice
+	got = recorder{}
+	l.Replay(&got)
+	l.Replay(&got)
+	if len(got.log) != 2*len(want.log) {
+		t.Fatalf("got %d calls after two replays, want %d", len(got.log), 2*len(want.log))
+	}
+}
+
+func TestDisplayList_ReplayBasic(t *testing.T) {
+	var (
+		l   DisplayList
+		got basicRecorder
+	)
+	record(&l)
+	l.Replay(&got)
+	want := []string{"Camera", "Clear", "Draw", "Draw"}
+	if len(got.log) != len(want) {
+		t.Fatalf("Replay: got %q", got.log)
+	}
+	for i, s := range want {
+		if got.log[i][:len(s)+1] != s+" " {
+			t.Errorf("call %d = %q, want %s", i, got.log[i], s)
+		}
+	}
+}
+
+// A flatRecorder logs calls to DrawTriangles, DrawQuad and DrawMesh, but has
+// no layers.
+//
+type flatRecorder struct {
+	basicRecorder
+}
+
+func (r *flatRecorder) DrawTriangles(d Drawable, v []Vertex)               { r.logf("DrawTriangles") }
+func (r *flatRecorder) DrawQuad(d Drawable, q *[4]Vertex)                  { r.logf("DrawQuad") }
+func (r *flatRecorder) DrawMesh(d Drawable, v []Vertex, indices []uint16) { r.logf("DrawMesh") }
+
+func TestDisplayList_ReplayFlat(t *testing.T) {
+	var (
+		l   DisplayList
+		got flatRecorder
+	)
+	record(&l)
+	l.Replay(&got)
+	want := []string{"DrawTriangles", "DrawQuad", "DrawMesh", "DrawTriangles", "DrawQuad", "DrawMesh"}
+	got.log = got.log[4:] // Camera, Clear, Draw, Draw
+	if !reflect.DeepEqual(got.log, want) {
+		t.Fatalf("Replay:\ngot  %q\nwant %q", got.log, want)
+	}
+}
+
+func TestDisplayList_ReplayList(t *testing.T) {
+	var src, dst DisplayList
+	m := record(&src)
+	src.Replay(&dst)
+	if !reflect.DeepEqual(src.Commands(), dst.Commands()) {
+		t.Fatal("commands differ after replaying into a DisplayList")
+	}
+	for _, c := range dst.Commands() {
+		if c.Type == CmdSetMaterial && c.Material != m {
+			t.Errorf("SetMaterial: got material %p, want %p", c.Material, m)
+		}
+	}
+}
+
+func TestDisplayList_copies(t *testing.T) {
+	var l DisplayList
+	v := []Vertex{{Pos: Pt(0, 0)}, {Pos: Pt(1, 0)}, {Pos: Pt(1, 1)}}
+	q := [4]Vertex{v[0], v[1], v[2], v[2]}
+	i := []uint16{0, 1, 2}
+	u := []float32{1, 2, 3}
+	l.DrawTriangles(nil, v)
+	l.DrawQuad(nil, &q)
+	l.DrawMesh(nil, v, i)
+	l.SetUniform("u", u...)
+
+	v[0].Pos, q[0].Pos, i[0], u[0] = Pt(9, 9), Pt(9, 9), 2, 9
+	cmds := l.Commands()
+	if p := cmds[0].Vertices[0].Pos; p != Pt(0, 0) {
+		t.Errorf("DrawTriangles: vertex 0 = %v, want (0, 0)", p)
+	}
+	if p := cmds[1].Vertices[0].Pos; p != Pt(0, 0) {
+		t.Errorf("DrawQuad: vertex 0 = %v, want (0, 0)", p)
+	}
+	if p := cmds[2].Vertices[0].Pos; p != Pt(0, 0) {
+		t.Errorf("DrawMesh: vertex 0 = %v, want (0, 0)", p)
+	}
+	if cmds[2].Indices[0] != 0 {
+		t.Errorf("DrawMesh: index 0 = %d, want 0", cmds[2].Indices[0])
+	}
+	if cmds[3].Values[0] != 1 {
+		t.Errorf("SetUniform: value 0 = %v, want 1", cmds[3].Values[0])
+	}
+	// recorded slices must not share storage with later commands
+	if cap(cmds[0].Vertices) != len(cmds[0].Vertices) {
+		t.Errorf("DrawTriangles: vertices have spare capacity %d", cap(cmds[0].Vertices)-len(cmds[0].Vertices))
+	}
+}
+
+func TestDisplayList_Reset(t *testing.T) {
+	var (
+		l   DisplayList
+		got recorder
+		d   = &testDrawable{1, image.Pt(1, 1)}
+	)
+	record(&l)
+	l.Reset()
+	if l.Len() != 0 {
+		t.Fatalf("Len() = %d after Reset, want 0", l.Len())
+	}
+	l.Replay(&got)
+	if len(got.log) != 0 {
+		t.Fatalf("Replay after Reset: got %q", got.log)
+	}
+
+	// storage is reused
+	l.DrawTriangles(d, []Vertex{{Pos: Pt(5, 5)}, {Pos: Pt(6, 5)}, {Pos: Pt(6, 6)}})
+	l.Draw(d, Pt(7, 7), Pt(1, 1), 0, nil)
+	l.Replay(&got)
+	want := []string{
+		"DrawTriangles tex1 [{(5.00,5.00) (0.00,0.00) {0 0 0 0} [0 0 0 0]} {(6.00,5.00) (0.00,0.00) {0 0 0 0} [0 0 0 0]} {(6.00,6.00) (0.00,0.00) {0 0 0 0} [0 0 0 0]}]",
+		"Draw tex1 (7.00,7.00) (1.00,1.00) 0 <nil>",
+	}
+	if !reflect.DeepEqual(got.log, want) {
+		t.Fatalf("Replay after Reset:\ngot  %q\nwant %q", got.log, want)
+	}
+}
+
+func TestCommandType_String(t *testing.T) {
+	for c := CmdDraw; c <= CmdDrawMeshLayer; c++ {
+		if s := c.String(); s == "Unknown" || s == "" {
+			t.Errorf("CommandType(%d).String() = %q", c, s)
+		}
+	}
+	if s := CommandType(-1).String(); s != "Unknown" {
+		t.Errorf("CommandType(-1).String() = %q, want Unknown", s)
+	}
+}
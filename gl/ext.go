@@ -0,0 +1,308 @@
+package gl
+
+/*
+#include "gl.h"
+#include <stddef.h>
+#include <stdint.h>
+
+#ifdef GOTAG_gles2
+#define EXT_APIENTRYP GL_APIENTRYP
+#else
+#define EXT_APIENTRYP APIENTRYP
+#endif
+
+enum {
+	EXT_GenVertexArrays,
+	EXT_BindVertexArray,
+	EXT_DeleteVertexArrays,
+	EXT_DrawArraysInstanced,
+	EXT_VertexAttribDivisor,
+	EXT_BufferStorage,
+	EXT_MapBufferRange,
+	EXT_FenceSync,
+	EXT_ClientWaitSync,
+	EXT_DeleteSync,
+	EXT_DrawElementsBaseVertex,
+	EXT_COUNT
+};
+
+static const char *extNames[EXT_COUNT] = {
+	"glGenVertexArrays",
+	"glBindVertexArray",
+	"glDeleteVertexArrays",
+	"glDrawArraysInstanced",
+	"glVertexAttribDivisor",
+	"glBufferStorage",
+	"glMapBufferRange",
+	"glFenceSync",
+	"glClientWaitSync",
+	"glDeleteSync",
+	"glDrawElementsBaseVertex",
+};
+
+static void *ext[EXT_COUNT];
+
+typedef void *(*extLoadProc)(const char *name);
+
+static const char *extName(int i) { return extNames[i]; }
+static void extSet(int i, void *p) { ext[i] = p; }
+static int extLoaded(int i) { return ext[i] != NULL; }
+
+static void extLoad(void *loader) {
+	int i;
+	for (i = 0; i < EXT_COUNT; i++) {
+		ext[i] = ((extLoadProc)loader)(extNames[i]);
+	}
+}
+
+typedef void (EXT_APIENTRYP PFNEXTGENVERTEXARRAYS)(GLsizei n, GLuint *arrays);
+typedef void (EXT_APIENTRYP PFNEXTBINDVERTEXARRAY)(GLuint array);
+typedef void (EXT_APIENTRYP PFNEXTDELETEVERTEXARRAYS)(GLsizei n, const GLuint *arrays);
+typedef void (EXT_APIENTRYP PFNEXTDRAWARRAYSINSTANCED)(GLenum mode, GLint first, GLsizei count, GLsizei instancecount);
+typedef void (EXT_APIENTRYP PFNEXTVERTEXATTRIBDIVISOR)(GLuint index, GLuint divisor);
+typedef void (EXT_APIENTRYP PFNEXTBUFFERSTORAGE)(GLenum target, GLsizeiptr size, const void *data, GLbitfield flags);
+typedef void *(EXT_APIENTRYP PFNEXTMAPBUFFERRANGE)(GLenum target, GLintptr offset, GLsizeiptr length, GLbitfield access);
+
+// GLsync is not defined by OpenGL 2.1 and OpenGLES 2 headers.
+typedef void *extSync;
+
+typedef extSync (EXT_APIENTRYP PFNEXTFENCESYNC)(GLenum condition, GLbitfield flags);
+typedef GLenum (EXT_APIENTRYP PFNEXTCLIENTWAITSYNC)(extSync sync, GLbitfield flags, uint64_t timeout);
+typedef void (EXT_APIENTRYP PFNEXTDELETESYNC)(extSync sync);
+typedef void (EXT_APIENTRYP PFNEXTDRAWELEMENTSBASEVERTEX)(GLenum mode, GLsizei count, GLenum type, const void *indices, GLint basevertex);
+
+static void extGenVertexArrays(GLsizei n, GLuint *arrays) {
+	((PFNEXTGENVERTEXARRAYS)ext[EXT_GenVertexArrays])(n, arrays);
+}
+
+static void extBindVertexArray(GLuint array) {
+	((PFNEXTBINDVERTEXARRAY)ext[EXT_BindVertexArray])(array);
+}
+
+static void extDeleteVertexArrays(GLsizei n, const GLuint *arrays) {
+	((PFNEXTDELETEVERTEXARRAYS)ext[EXT_DeleteVertexArrays])(n, arrays);
+}
+
+static void extDrawArraysInstanced(GLenum mode, GLint first, GLsizei count, GLsizei instancecount) {
+	((PFNEXTDRAWARRAYSINSTANCED)ext[EXT_DrawArraysInstanced])(mode, first, count, instancecount);
+}
+
+static void extVertexAttribDivisor(GLuint index, GLuint divisor) {
+	((PFNEXTVERTEXATTRIBDIVISOR)ext[EXT_VertexAttribDivisor])(index, divisor);
+}
+
+static void extBufferStorage(GLenum target, GLsizeiptr size, const void *data, GLbitfield flags) {
+	((PFNEXTBUFFERSTORAGE)ext[EXT_BufferStorage])(target, size, data, flags);
+}
+
+static void *extMapBufferRange(GLenum target, GLintptr offset, GLsizeiptr length, GLbitfield access) {
+	return ((PFNEXTMAPBUFFERRANGE)ext[EXT_MapBufferRange])(target, offset, length, access);
+}
+
+static extSync extFenceSync(GLenum condition, GLbitfield flags) {
+	return ((PFNEXTFENCESYNC)ext[EXT_FenceSync])(condition, flags);
+}
+
+static GLenum extClientWaitSync(extSync sync, GLbitfield flags, uint64_t timeout) {
+	return ((PFNEXTCLIENTWAITSYNC)ext[EXT_ClientWaitSync])(sync, flags, timeout);
+}
+
+static void extDeleteSync(extSync sync) {
+	((PFNEXTDELETESYNC)ext[EXT_DeleteSync])(sync);
+}
+
+static void extDrawElementsBaseVertex(GLenum mode, GLsizei count, GLenum type, GLintptr offset, GLint basevertex) {
+	((PFNEXTDRAWELEMENTSBASEVERTEX)ext[EXT_DrawElementsBaseVertex])(mode, count, type, (void *)offset, basevertex);
+}
+*/
+import "C"
+
+import "unsafe"
+
+// Constants used by extension functions.
+//
+const (
+	GL_CONTEXT_PROFILE_MASK       = 0x9126
+	GL_CONTEXT_CORE_PROFILE_BIT   = 0x00000001
+	GL_VERTEX_ARRAY_BINDING       = 0x85B5
+	GL_MAP_WRITE_BIT              = 0x0002
+	GL_MAP_PERSISTENT_BIT         = 0x0040
+	GL_MAP_COHERENT_BIT           = 0x0080
+	GL_SYNC_GPU_COMMANDS_COMPLETE = 0x9117
+	GL_SYNC_FLUSH_COMMANDS_BIT    = 0x00000001
+	GL_ALREADY_SIGNALED           = 0x911A
+	GL_TIMEOUT_EXPIRED            = 0x911B
+	GL_CONDITION_SATISFIED        = 0x911C
+	GL_WAIT_FAILED                = 0x911D
+)
+
+// InitExtC loads extension functions: OpenGL functions that are not part of the
+// API version of the package (see APIVersion), but that can be used when the
+// runtime version supports them. It must be called after InitC. Extension
+// functions must not be called if the corresponding Has* function returns
+// false.
+//
+// loader is a function pointer to a C function of type
+//
+//  typedef void *(*loader) (const char *funcName)
+//
+// that returns NULL for unknown functions.
+//
+func InitExtC(loader unsafe.Pointer) {
+	C.extLoad(loader)
+	fboLoadC(loader)
+}
+
+// InitExtGo loads extension functions (see InitExtC). The recommended value
+// for loader is glfw.GetProcAddress. Unlike InitGo, functions that the loader
+// cannot find are not an error: loader may either return nil or panic.
+//
+func InitExtGo(loader func(string) unsafe.Pointer) {
+	for i := 0; i < int(C.EXT_COUNT); i++ {
+		C.extSet(C.int(i), extLookup(loader, C.GoString(C.extName(C.int(i)))))
+	}
+	fboLoadGo(loader)
+}
+
+func extLookup(loader func(string) unsafe.Pointer, name string) (p unsafe.Pointer) {
+	defer func() {
+		if recover() != nil {
+			p = nil
+		}
+	}()
+	return loader(name)
+}
+
+// extLoaded returns true if all the given extension functions are loaded.
+//
+func extLoaded(fns ...C.int) bool {
+	for _, i := range fns {
+		if C.extLoaded(i) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// RuntimeCoreProfile returns true if the current context is an OpenGL core
+// profile context. This is always true if CoreProfile is true, and always
+// false for OpenGLES contexts or OpenGL versions older than 3.2.
+//
+func RuntimeCoreProfile() bool {
+	if CoreProfile {
+		return true
+	}
+	if !RuntimeVersion().GE(OpenGL, 3, 2) {
+		return false
+	}
+	var mask int32
+	GetIntegerv(GL_CONTEXT_PROFILE_MASK, &mask)
+	return mask&GL_CONTEXT_CORE_PROFILE_BIT != 0
+}
+
+// HasVertexArrays returns true if vertex array objects are available: the
+// runtime version is OpenGL 3.0 or OpenGLES 3.0 or higher, and GenVertexArrays,
+// BindVertexArray and DeleteVertexArrays have been loaded.
+//
+func HasVertexArrays() bool {
+	v := RuntimeVersion()
+	return (v.GE(OpenGL, 3, 0) || v.GE(OpenGLES, 3, 0)) &&
+		extLoaded(C.EXT_GenVertexArrays, C.EXT_BindVertexArray, C.EXT_DeleteVertexArrays)
+}
+
+// GenVertexArrays generates vertex array object names.
+//
+func GenVertexArrays(n int32, arrays *uint32) {
+	C.extGenVertexArrays(C.GLsizei(n), (*C.GLuint)(unsafe.Pointer(arrays)))
+}
+
+// BindVertexArray binds a vertex array object.
+//
+func BindVertexArray(array uint32) {
+	C.extBindVertexArray(C.GLuint(array))
+}
+
+// DeleteVertexArrays deletes vertex array objects.
+//
+func DeleteVertexArrays(n int32, arrays *uint32) {
+	C.extDeleteVertexArrays(C.GLsizei(n), (*C.GLuint)(unsafe.Pointer(arrays)))
+}
+
+// HasInstancing returns true if instanced drawing is available: the runtime
+// version is OpenGL 3.3 or OpenGLES 3.0 or higher, and DrawArraysInstanced and
+// VertexAttribDivisor have been loaded.
+//
+func HasInstancing() bool {
+	v := RuntimeVersion()
+	return (v.GE(OpenGL, 3, 3) || v.GE(OpenGLES, 3, 0)) &&
+		extLoaded(C.EXT_DrawArraysInstanced, C.EXT_VertexAttribDivisor)
+}
+
+// DrawArraysInstanced draws multiple instances of a range of elements.
+//
+func DrawArraysInstanced(mode uint32, first int32, count int32, instanceCount int32) {
+	C.extDrawArraysInstanced(C.GLenum(mode), C.GLint(first), C.GLsizei(count), C.GLsizei(instanceCount))
+}
+
+// VertexAttribDivisor sets the rate at which a generic vertex attribute
+// advances during instanced rendering.
+//
+func VertexAttribDivisor(index uint32, divisor uint32) {
+	C.extVertexAttribDivisor(C.GLuint(index), C.GLuint(divisor))
+}
+
+// HasBufferStorage returns true if persistently mapped buffers can be used:
+// the runtime version is OpenGL 4.4 or higher, and BufferStorage,
+// MapBufferRange, FenceSync, ClientWaitSync, DeleteSync and
+// DrawElementsBaseVertexOffset have been loaded.
+//
+func HasBufferStorage() bool {
+	return RuntimeVersion().GE(OpenGL, 4, 4) &&
+		extLoaded(C.EXT_BufferStorage, C.EXT_MapBufferRange, C.EXT_FenceSync,
+			C.EXT_ClientWaitSync, C.EXT_DeleteSync, C.EXT_DrawElementsBaseVertex)
+}
+
+// BufferStorage creates the immutable data store of the buffer bound to target.
+//
+func BufferStorage(target uint32, size int, data unsafe.Pointer, flags uint32) {
+	C.extBufferStorage(C.GLenum(target), C.GLsizeiptr(size), data, C.GLbitfield(flags))
+}
+
+// MapBufferRange maps a range of the data store of the buffer bound to target
+// and returns a pointer to it.
+//
+func MapBufferRange(target uint32, offset int, length int, access uint32) unsafe.Pointer {
+	return C.extMapBufferRange(C.GLenum(target), C.GLintptr(offset), C.GLsizeiptr(length), C.GLbitfield(access))
+}
+
+// A Sync is a sync object.
+//
+type Sync struct {
+	s C.extSync
+}
+
+// FenceSync creates a fence sync object.
+//
+func FenceSync(condition uint32, flags uint32) Sync {
+	return Sync{C.extFenceSync(C.GLenum(condition), C.GLbitfield(flags))}
+}
+
+// ClientWaitSync waits for a sync object to become signaled, for at most
+// timeout nanoseconds.
+//
+func ClientWaitSync(s Sync, flags uint32, timeout uint64) uint32 {
+	return uint32(C.extClientWaitSync(s.s, C.GLbitfield(flags), C.uint64_t(timeout)))
+}
+
+// DeleteSync deletes a sync object.
+//
+func DeleteSync(s Sync) {
+	C.extDeleteSync(s.s)
+}
+
+// DrawElementsBaseVertexOffset is a variant of DrawElementsBaseVertex where
+// indices is an offset in the bound element array buffer.
+//
+func DrawElementsBaseVertexOffset(mode uint32, count int32, type_ uint32, offset int, baseVertex int32) {
+	C.extDrawElementsBaseVertex(C.GLenum(mode), C.GLsizei(count), C.GLenum(type_), C.GLintptr(offset), C.GLint(baseVertex))
+}
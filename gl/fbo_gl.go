@@ -0,0 +1,213 @@
+// +build !gles2 darwin
+
+package gl
+
+/*
+#include "gl.h"
+#include <stddef.h>
+
+enum {
+	FBO_GenFramebuffers,
+	FBO_BindFramebuffer,
+	FBO_DeleteFramebuffers,
+	FBO_FramebufferTexture2D,
+	FBO_CheckFramebufferStatus,
+	FBO_GenRenderbuffers,
+	FBO_BindRenderbuffer,
+	FBO_DeleteRenderbuffers,
+	FBO_RenderbufferStorage,
+	FBO_FramebufferRenderbuffer,
+	FBO_COUNT
+};
+
+static const char *fboNames[FBO_COUNT] = {
+	"glGenFramebuffers",
+	"glBindFramebuffer",
+	"glDeleteFramebuffers",
+	"glFramebufferTexture2D",
+	"glCheckFramebufferStatus",
+	"glGenRenderbuffers",
+	"glBindRenderbuffer",
+	"glDeleteRenderbuffers",
+	"glRenderbufferStorage",
+	"glFramebufferRenderbuffer",
+};
+
+static void *fbo[FBO_COUNT];
+
+typedef void *(*fboLoadProc)(const char *name);
+
+static const char *fboName(int i) { return fboNames[i]; }
+static void fboSet(int i, void *p) { fbo[i] = p; }
+static int fboLoaded(void) {
+	int i;
+	for (i = 0; i < FBO_COUNT; i++) {
+		if (fbo[i] == NULL) {
+			return 0;
+		}
+	}
+	return 1;
+}
+
+static void fboLoad(void *loader) {
+	int i;
+	for (i = 0; i < FBO_COUNT; i++) {
+		fbo[i] = ((fboLoadProc)loader)(fboNames[i]);
+	}
+}
+
+typedef void (APIENTRYP PFNFBOGENFRAMEBUFFERS)(GLsizei n, GLuint *framebuffers);
+typedef void (APIENTRYP PFNFBOBINDFRAMEBUFFER)(GLenum target, GLuint framebuffer);
+typedef void (APIENTRYP PFNFBODELETEFRAMEBUFFERS)(GLsizei n, const GLuint *framebuffers);
+typedef void (APIENTRYP PFNFBOFRAMEBUFFERTEXTURE2D)(GLenum target, GLenum attachment, GLenum textarget, GLuint texture, GLint level);
+typedef GLenum (APIENTRYP PFNFBOCHECKFRAMEBUFFERSTATUS)(GLenum target);
+typedef void (APIENTRYP PFNFBOGENRENDERBUFFERS)(GLsizei n, GLuint *renderbuffers);
+typedef void (APIENTRYP PFNFBOBINDRENDERBUFFER)(GLenum target, GLuint renderbuffer);
+typedef void (APIENTRYP PFNFBODELETERENDERBUFFERS)(GLsizei n, const GLuint *renderbuffers);
+typedef void (APIENTRYP PFNFBORENDERBUFFERSTORAGE)(GLenum target, GLenum internalformat, GLsizei width, GLsizei height);
+typedef void (APIENTRYP PFNFBOFRAMEBUFFERRENDERBUFFER)(GLenum target, GLenum attachment, GLenum renderbuffertarget, GLuint renderbuffer);
+
+static void fboGenFramebuffers(GLsizei n, GLuint *framebuffers) {
+	((PFNFBOGENFRAMEBUFFERS)fbo[FBO_GenFramebuffers])(n, framebuffers);
+}
+
+static void fboBindFramebuffer(GLenum target, GLuint framebuffer) {
+	((PFNFBOBINDFRAMEBUFFER)fbo[FBO_BindFramebuffer])(target, framebuffer);
+}
+
+static void fboDeleteFramebuffers(GLsizei n, const GLuint *framebuffers) {
+	((PFNFBODELETEFRAMEBUFFERS)fbo[FBO_DeleteFramebuffers])(n, framebuffers);
+}
+
+static void fboFramebufferTexture2D(GLenum target, GLenum attachment, GLenum textarget, GLuint texture, GLint level) {
+	((PFNFBOFRAMEBUFFERTEXTURE2D)fbo[FBO_FramebufferTexture2D])(target, attachment, textarget, texture, level);
+}
+
+static GLenum fboCheckFramebufferStatus(GLenum target) {
+	return ((PFNFBOCHECKFRAMEBUFFERSTATUS)fbo[FBO_CheckFramebufferStatus])(target);
+}
+
+static void fboGenRenderbuffers(GLsizei n, GLuint *renderbuffers) {
+	((PFNFBOGENRENDERBUFFERS)fbo[FBO_GenRenderbuffers])(n, renderbuffers);
+}
+
+static void fboBindRenderbuffer(GLenum target, GLuint renderbuffer) {
+	((PFNFBOBINDRENDERBUFFER)fbo[FBO_BindRenderbuffer])(target, renderbuffer);
+}
+
+static void fboDeleteRenderbuffers(GLsizei n, const GLuint *renderbuffers) {
+	((PFNFBODELETERENDERBUFFERS)fbo[FBO_DeleteRenderbuffers])(n, renderbuffers);
+}
+
+static void fboRenderbufferStorage(GLenum target, GLenum internalformat, GLsizei width, GLsizei height) {
+	((PFNFBORENDERBUFFERSTORAGE)fbo[FBO_RenderbufferStorage])(target, internalformat, width, height);
+}
+
+static void fboFramebufferRenderbuffer(GLenum target, GLenum attachment, GLenum renderbuffertarget, GLuint renderbuffer) {
+	((PFNFBOFRAMEBUFFERRENDERBUFFER)fbo[FBO_FramebufferRenderbuffer])(target, attachment, renderbuffertarget, renderbuffer);
+}
+*/
+import "C"
+
+import "unsafe"
+
+// Framebuffer object constants. OpenGL 2.1 does not define them, but they are
+// the same in OpenGL 3.0 and OpenGLES 2.0.
+//
+const (
+	GL_FRAMEBUFFER          = 0x8D40
+	GL_RENDERBUFFER         = 0x8D41
+	GL_FRAMEBUFFER_BINDING  = 0x8CA6
+	GL_FRAMEBUFFER_COMPLETE = 0x8CD5
+	GL_COLOR_ATTACHMENT0    = 0x8CE0
+	GL_DEPTH_ATTACHMENT     = 0x8D00
+	GL_STENCIL_ATTACHMENT   = 0x8D20
+	GL_STENCIL_INDEX8       = 0x8D48
+	GL_DEPTH24_STENCIL8     = 0x88F0
+)
+
+// fboLoadC loads the framebuffer object functions with a C loader.
+//
+func fboLoadC(loader unsafe.Pointer) {
+	C.fboLoad(loader)
+}
+
+// fboLoadGo loads the framebuffer object functions with a Go loader.
+//
+func fboLoadGo(loader func(string) unsafe.Pointer) {
+	for i := 0; i < int(C.FBO_COUNT); i++ {
+		C.fboSet(C.int(i), extLookup(loader, C.GoString(C.fboName(C.int(i)))))
+	}
+}
+
+// HasFramebuffers returns true if framebuffer objects are available: the
+// runtime version is OpenGL 3.0 or higher, and the framebuffer object
+// functions have been loaded. Framebuffer objects are always available with
+// OpenGLES.
+//
+func HasFramebuffers() bool {
+	return RuntimeVersion().GE(OpenGL, 3, 0) && C.fboLoaded() != 0
+}
+
+// GenFramebuffers generates framebuffer object names.
+//
+func GenFramebuffers(n int32, framebuffers *uint32) {
+	C.fboGenFramebuffers(C.GLsizei(n), (*C.GLuint)(unsafe.Pointer(framebuffers)))
+}
+
+// BindFramebuffer binds a framebuffer to a framebuffer target.
+//
+func BindFramebuffer(target uint32, framebuffer uint32) {
+	C.fboBindFramebuffer(C.GLenum(target), C.GLuint(framebuffer))
+}
+
+// DeleteFramebuffers deletes framebuffer objects.
+//
+func DeleteFramebuffers(n int32, framebuffers *uint32) {
+	C.fboDeleteFramebuffers(C.GLsizei(n), (*C.GLuint)(unsafe.Pointer(framebuffers)))
+}
+
+// FramebufferTexture2D attaches a level of a texture object as a logical
+// buffer of a framebuffer object.
+//
+func FramebufferTexture2D(target uint32, attachment uint32, textarget uint32, texture uint32, level int32) {
+	C.fboFramebufferTexture2D(C.GLenum(target), C.GLenum(attachment), C.GLenum(textarget), C.GLuint(texture), C.GLint(level))
+}
+
+// CheckFramebufferStatus checks the completeness status of a framebuffer.
+//
+func CheckFramebufferStatus(target uint32) uint32 {
+	return uint32(C.fboCheckFramebufferStatus(C.GLenum(target)))
+}
+
+// GenRenderbuffers generates renderbuffer object names.
+//
+func GenRenderbuffers(n int32, renderbuffers *uint32) {
+	C.fboGenRenderbuffers(C.GLsizei(n), (*C.GLuint)(unsafe.Pointer(renderbuffers)))
+}
+
+// BindRenderbuffer binds a renderbuffer to a renderbuffer target.
+//
+func BindRenderbuffer(target uint32, renderbuffer uint32) {
+	C.fboBindRenderbuffer(C.GLenum(target), C.GLuint(renderbuffer))
+}
+
+// DeleteRenderbuffers deletes renderbuffer objects.
+//
+func DeleteRenderbuffers(n int32, renderbuffers *uint32) {
+	C.fboDeleteRenderbuffers(C.GLsizei(n), (*C.GLuint)(unsafe.Pointer(renderbuffers)))
+}
+
+// RenderbufferStorage establishes the data storage, format and dimensions of a
+// renderbuffer object's image.
+//
+func RenderbufferStorage(target uint32, internalformat uint32, width int32, height int32) {
+	C.fboRenderbufferStorage(C.GLenum(target), C.GLenum(internalformat), C.GLsizei(width), C.GLsizei(height))
+}
+
+// FramebufferRenderbuffer attaches a renderbuffer as a logical buffer of a
+// framebuffer object.
+//
+func FramebufferRenderbuffer(target uint32, attachment uint32, renderbuffertarget uint32, renderbuffer uint32) {
+	C.fboFramebufferRenderbuffer(C.GLenum(target), C.GLenum(attachment), C.GLenum(renderbuffertarget), C.GLuint(renderbuffer))
+}
@@ -0,0 +1,21 @@
+// +build gles2,!darwin
+
+package gl
+
+import "unsafe"
+
+// GL_DEPTH24_STENCIL8 is defined by OpenGLES 3.0 and OES_packed_depth_stencil.
+//
+const GL_DEPTH24_STENCIL8 = 0x88F0
+
+// Framebuffer object functions are part of OpenGLES 2.0.
+
+func fboLoadC(loader unsafe.Pointer)               {}
+func fboLoadGo(loader func(string) unsafe.Pointer) {}
+
+// HasFramebuffers returns true if framebuffer objects are available. This is
+// always true with OpenGLES.
+//
+func HasFramebuffers() bool {
+	return true
+}
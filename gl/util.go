@@ -10,6 +10,10 @@ static void vertexAttribOffset(GLuint index, GLint size, GLenum type_, GLboolean
 	glVertexAttribPointer(index, size, type_, normalized, stride, (void *)offset);
 }
 
+static void drawElementsOffset(GLenum mode, GLsizei count, GLenum type_, GLintptr offset) {
+	glDrawElements(mode, count, type_, (void *)offset);
+}
+
 static const char *newShader(GLuint *shaderPtr, GLenum shaderType, const char *src, GLint len) {
 	char *err = NULL;
 	GLuint shader = glCreateShader(shaderType);
@@ -88,7 +92,9 @@ static void batchDraw(GLuint tex, GLfloat *vertices, GLsizeiptr size) {
 import "C"
 
 import (
+	"fmt"
 	"image/color"
+	"strings"
 	"unsafe"
 
 	"golang.org/x/xerrors"
@@ -100,12 +106,30 @@ func GetGoString(name uint32) string {
 	return C.GoString((*C.char)(unsafe.Pointer(GetString(name))))
 }
 
+// HasExtension returns true if the named extension is listed in the
+// GL_EXTENSIONS string of the current context.
+//
+func HasExtension(name string) bool {
+	for _, ext := range strings.Fields(GetGoString(GL_EXTENSIONS)) {
+		if ext == name {
+			return true
+		}
+	}
+	return false
+}
+
 // VertexAttribOffset is a variant of VertexAttribPointer for cases where pointer is an offset and not a real pointer.
 //
 func VertexAttribOffset(index uint32, size int32, type_ uint32, normalized byte, stride int32, offset int) {
 	C.vertexAttribOffset(C.GLuint(index), C.GLint(size), C.GLenum(type_), C.GLboolean(normalized), C.GLsizei(stride), C.GLintptr(offset))
 }
 
+// DrawElementsOffset is a variant of DrawElements for cases where indices is an offset in the bound element array buffer and not a real pointer.
+//
+func DrawElementsOffset(mode uint32, count int32, type_ uint32, offset int) {
+	C.drawElementsOffset(C.GLenum(mode), C.GLsizei(count), C.GLenum(type_), C.GLintptr(offset))
+}
+
 func Sizeof(v interface{}) int {
 	switch v := v.(type) {
 	case []int8:
@@ -237,6 +261,39 @@ func (p Program) UniformLocation(name string) int32 {
 	return int32(C.getUniformLocation(C.GLuint(p), C.CString(name)))
 }
 
+// CheckUniform panics if v is not a valid value for SetUniform.
+//
+func CheckUniform(v []float32) {
+	switch len(v) {
+	case 1, 2, 3, 4, 9, 16:
+	default:
+		panic(fmt.Sprintf("invalid uniform value size %d", len(v)))
+	}
+}
+
+// SetUniform sets the value of the uniform at location loc in the current
+// program. The size of v selects the uniform type: float, vec2, vec3, vec4,
+// mat3 or mat4. It panics if v has any other size.
+//
+func SetUniform(loc int32, v []float32) {
+	switch len(v) {
+	case 1:
+		Uniform1f(loc, v[0])
+	case 2:
+		Uniform2f(loc, v[0], v[1])
+	case 3:
+		Uniform3f(loc, v[0], v[1], v[2])
+	case 4:
+		Uniform4f(loc, v[0], v[1], v[2], v[3])
+	case 9:
+		UniformMatrix3fv(loc, 1, GL_FALSE, &v[0])
+	case 16:
+		UniformMatrix4fv(loc, 1, GL_FALSE, &v[0])
+	default:
+		CheckUniform(v)
+	}
+}
+
 // func BatchBegin(program Program, vbo uint32, ebo uint32, aPos uint32, aColor uint32, uTexture int32) {
 // 	C.batchBegin(C.GLuint(program), C.GLuint(vbo), C.GLuint(ebo), C.GLuint(aPos), C.GLuint(aColor), C.GLint(uTexture))
 // }
@@ -0,0 +1,25 @@
+// +build egl
+
+package grog
+
+import (
+	"testing"
+
+	"github.com/db47h/grog/internal/egl"
+)
+
+// Size of the default frame buffer of the test context.
+//
+const glWidth, glHeight = egl.Width, egl.Height
+
+// glContext makes a headless OpenGL context current on the calling goroutine,
+// creating it on first use, and returns a function that releases it. Tests
+// that need OpenGL only run with the egl build tag.
+//
+func glContext(tb testing.TB) (release func()) {
+	release, err := egl.Acquire()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return release
+}
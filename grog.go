@@ -3,6 +3,8 @@ package grog
 import (
 	"image"
 	"image/color"
+
+	"github.com/db47h/grog/gl"
 )
 
 // Drawable wraps the methods of drawable objects like texture.Texture and
@@ -16,6 +18,22 @@ type Drawable interface {
 	UV() [4]float32      // UV coordinates of the drawable in the associated texture
 }
 
+// A Vertex is a vertex of a textured triangle. Pos is in world coordinates, UV
+// are the texture coordinates and Color is multiplied with the texture color.
+//
+// If Wrap is not zero, it is the region of the texture within which UV
+// coordinates wrap around, in the same format as Drawable.UV, and UV is given
+// in copies of the region: (0, 0) is the top left corner of the region, (1, 1)
+// its bottom right corner, and (2.5, 0) half way through the third copy to the
+// right. All vertices of a triangle must have the same Wrap. See FillWrapped.
+//
+type Vertex struct {
+	Pos   Point
+	UV    Point
+	Color gl.Color
+	Wrap  [4]float32
+}
+
 type Renderer interface {
 	Draw(d Drawable, dp, scale Point, rot float32, c color.Color)
 	Camera(Camera)
@@ -24,12 +42,250 @@ type Renderer interface {
 
 type BatchRenderer interface {
 	Renderer
+	SetBlendMode(BlendMode)
 	Begin()
 	Flush()
 	End()
 	Close()
 }
 
+// MaterialRenderer is implemented by BatchRenderers that support custom shader
+// materials. Batches returned by NewBatch implement MaterialRenderer.
+//
+// Changing the material or setting a uniform flushes the batch if needed. The
+// current material remains in use until changed; in particular it is not reset
+// by Begin or End.
+//
+//	b.SetMaterial(dissolve)
+//	b.SetUniform("uTime", float32(t.Seconds()))
+//	b.Draw(sprite, pos, scale, 0, nil)
+//	b.SetMaterial(nil) // back to the default material
+//
+type MaterialRenderer interface {
+	BatchRenderer
+	SetMaterial(m *Material)
+	SetUniform(name string, v ...float32)
+}
+
+// LayeredRenderer is implemented by BatchRenderers that can sort draw calls by
+// layer. Batches returned by NewBatch implement LayeredRenderer.
+//
+// In sorted mode, draw calls are queued until the next call to Flush, End,
+// Clear, Camera or any other state change. The queued draw calls are then
+// drawn by increasing layer; Draw, DrawTriangles, DrawQuad and DrawMesh use
+// layer 0 (see LayeredMeshRenderer for layered variants). Within a layer, draw
+// calls are grouped by texture to minimize flushes, otherwise call order is
+// preserved.
+// Sprites that overlap must therefore be drawn in different layers, or use the
+// same texture.
+//
+//	b.SetSorted(true)
+//	for _, e := range entities {
+//		b.DrawLayer(e.z, e.sprite, e.pos, grog.Pt(1, 1), 0, nil)
+//	}
+//	b.End()
+//
+type LayeredRenderer interface {
+	BatchRenderer
+	SetSorted(sorted bool)
+	DrawLayer(layer int, d Drawable, dp, scale Point, rot float32, c color.Color)
+}
+
+// ShapeRenderer is implemented by BatchRenderers that can draw arbitrary
+// textured triangles. Batches returned by NewBatch implement ShapeRenderer.
+//
+// DrawTriangles draws len(v)/3 triangles textured with d. If d is nil, the
+// renderer's built-in 1x1 white texture is used, in which case UV coordinates
+// are irrelevant. Triangles are batched together with regular Draw calls.
+//
+// See ShapeDrawer for a higher level API.
+//
+type ShapeRenderer interface {
+	BatchRenderer
+	DrawTriangles(d Drawable, v []Vertex)
+}
+
+// MeshRenderer is implemented by ShapeRenderers that can also draw arbitrary
+// quads and indexed triangle meshes. Batches returned by NewBatch implement
+// MeshRenderer.
+//
+// DrawQuad draws a quad whose corners q are given in perimeter order, either
+// clockwise or counterclockwise. It is split into triangles (q[0], q[1], q[3])
+// and (q[3], q[1], q[2]).
+//
+// DrawMesh draws the triangles formed by consecutive triplets of indices into
+// v. It panics if the number of indices is not a multiple of 3 or if an index
+// is out of range.
+//
+// Like DrawTriangles, a nil Drawable selects the renderer's built-in white
+// texture. Vertex UV coordinates are relative to the whole texture of d; the
+// coordinates of a region can be obtained with its UV method.
+//
+//	// horizontal gradient
+//	q := [4]grog.Vertex{
+//		{Pos: grog.Pt(0, 0), Color: red}, {Pos: grog.Pt(100, 0), Color: blue},
+//		{Pos: grog.Pt(100, 50), Color: blue}, {Pos: grog.Pt(0, 50), Color: red},
+//	}
+//	b.DrawQuad(nil, &q)
+//
+type MeshRenderer interface {
+	ShapeRenderer
+	DrawQuad(d Drawable, q *[4]Vertex)
+	DrawMesh(d Drawable, v []Vertex, indices []uint16)
+}
+
+// LayeredMeshRenderer is implemented by renderers that can queue triangles,
+// quads and meshes in a given layer, so that shapes and meshes can be
+// interleaved with layered sprites. Batches returned by NewBatch implement
+// LayeredMeshRenderer.
+//
+// LayeredMeshRenderer has the methods of both LayeredRenderer and
+// MeshRenderer. DrawTrianglesLayer, DrawQuadLayer and DrawMeshLayer work like
+// DrawTriangles, DrawQuad and DrawMesh. In sorted mode, they are queued in the
+// given layer like DrawLayer; otherwise the layer is ignored.
+//
+type LayeredMeshRenderer interface {
+	MeshRenderer
+	SetSorted(sorted bool)
+	DrawLayer(layer int, d Drawable, dp, scale Point, rot float32, c color.Color)
+	DrawTrianglesLayer(layer int, d Drawable, v []Vertex)
+	DrawQuadLayer(layer int, d Drawable, q *[4]Vertex)
+	DrawMeshLayer(layer int, d Drawable, v []Vertex, indices []uint16)
+}
+
+// StatsRenderer is implemented by BatchRenderers that collect rendering
+// statistics. Batches returned by NewBatch implement StatsRenderer.
+//
+// Statistics are reset by Begin. Since the concurrent batch draws a buffer
+// while the next one is being filled, statistics are only complete after End
+// or Flush.
+//
+type StatsRenderer interface {
+	BatchRenderer
+	Stats() Stats
+}
+
+// CullingRenderer is implemented by BatchRenderers that skip draw calls
+// entirely outside of the clipping region of the current camera. Batches
+// returned by NewBatch implement CullingRenderer.
+//
+// Culling is enabled by default. Sprites, quads, triangles and meshes are
+// tested against the world space bounding box of the camera's clipping region
+// before any vertices are computed, and culled quads are counted in
+// Stats.Culled. For a *View, or a CameraState recorded from a *View, the
+// clipping region is its view rectangle. For other cameras, it is the whole
+// frame buffer.
+//
+// Culling assumes that vertices are not moved by the vertex shader. Disable it
+// with SetCulling(false) when using custom materials that do so.
+//
+type CullingRenderer interface {
+	BatchRenderer
+	SetCulling(enabled bool)
+}
+
+// SnapRenderer is implemented by BatchRenderers that can snap sprites to whole
+// frame buffer pixels, which prevents pixel art from jittering or bleeding
+// when a view origin or sprite positions are not integral. Batches returned by
+// NewBatch implement SnapRenderer.
+//
+// Snapping is disabled by default. When enabled, the edges of sprites drawn
+// with Draw are rounded to the nearest pixel boundary in screen space. Since
+// texture regions have their UV coordinates on texel edges, pixel centers then
+// sample texel centers at scale 1. At integer scales (View.Scale and sprite
+// scale combined), each pixel samples inside a single texel, which gives
+// pixel-perfect results with Nearest filtering. Rotated sprites, and all
+// sprites drawn with a rotated camera, are not snapped. Shapes, quads and
+// meshes are never snapped.
+//
+// For a *View, or a CameraState recorded from a *View, pixels are those of its
+// parent frame buffer. For other cameras, they are those of the current GL
+// viewport.
+//
+type SnapRenderer interface {
+	BatchRenderer
+	SetPixelSnap(enabled bool)
+}
+
+// ClipRenderer is implemented by BatchRenderers that support clip masks of
+// any shape. Batches returned by NewBatch implement ClipRenderer.
+//
+// BeginClip pushes a new clip mask: until EndClip, draw calls do not draw
+// anything but define the mask. Sprites, shapes and meshes can be used, and
+// fragments with an alpha value lower than 0.5 are not part of the mask. After
+// EndClip, draw calls are clipped to the intersection of the mask and of any
+// mask pushed before. PopClip removes the last mask. Masks can be nested up to
+// 255 levels deep and are reset by Begin.
+//
+// Clip masks are implemented with the stencil buffer, which the frame buffer
+// must have. Masks are recorded in screen space: they are not affected by
+// later camera changes, but PopClip must be called with a camera whose
+// clipping region covers the mask. Clear is not clipped by masks.
+//
+//	// circular radar
+//	b.Camera(radarView)
+//	b.BeginClip()
+//	sd.FillCircle(b, center, radius, nil)
+//	b.EndClip()
+//	// draw blips
+//	// ...
+//	b.PopClip()
+//
+type ClipRenderer interface {
+	BatchRenderer
+	BeginClip()
+	EndClip()
+	PopClip()
+}
+
+// ParallelRenderer is implemented by BatchRenderers that accept draw calls
+// recorded by several goroutines. Batches returned by NewBatch implement
+// ParallelRenderer.
+//
+// CommandBuffer returns the command buffer identified by order, creating it if
+// needed. It is safe for concurrent use, but each command buffer must only be
+// used by one goroutine at a time. Recording does not require the GL context,
+// so command buffers can be filled from any goroutine.
+//
+// Flush and End replay all command buffers into the batch from the GL thread,
+// in ascending order regardless of recording order or timing, after any draw
+// calls made directly to the batch, then reset them. Recording must be
+// complete by then.
+//
+// Command buffers are DisplayLists and record the same calls: sprites, layers,
+// shapes, meshes, cameras, blend modes, materials and clip masks. State
+// changes recorded in a command buffer remain in effect for subsequent buffers
+// and for later draw calls. Begin, Flush, End, and the methods of
+// SnapRenderer, CullingRenderer, UploadRenderer and StatsRenderer cannot be
+// recorded: call them on the batch itself.
+//
+//	var wg sync.WaitGroup
+//	for i, sys := range systems {
+//		wg.Add(1)
+//		go func(cb *grog.DisplayList, sys System) {
+//			sys.Render(cb)
+//			wg.Done()
+//		}(b.CommandBuffer(i), sys)
+//	}
+//	wg.Wait()
+//	b.End()
+//
+type ParallelRenderer interface {
+	BatchRenderer
+	CommandBuffer(order int) *DisplayList
+}
+
+// UploadRenderer is implemented by BatchRenderers with a configurable vertex
+// upload strategy. Batches returned by NewBatch implement UploadRenderer.
+//
+// SetUploadStrategy flushes the batch and returns the strategy in use, which is
+// UploadRing if s is not supported by the current context.
+//
+type UploadRenderer interface {
+	BatchRenderer
+	SetUploadStrategy(s UploadStrategy) UploadStrategy
+}
+
 type Camera interface {
 	ProjectionMatrix() [16]float32
 	GLRect() image.Rectangle
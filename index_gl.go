@@ -0,0 +1,11 @@
+// +build !gles2
+
+package grog
+
+import "github.com/db47h/grog/gl"
+
+// indexType returns the element index type used by batches.
+//
+func indexType() uint32 {
+	return gl.GL_UNSIGNED_INT
+}
@@ -0,0 +1,16 @@
+// +build gles2
+
+package grog
+
+import "github.com/db47h/grog/gl"
+
+// indexType returns the element index type used by batches. OpenGL ES 2.0 only
+// guarantees support for GL_UNSIGNED_SHORT indices, GL_UNSIGNED_INT requires
+// the OES_element_index_uint extension.
+//
+func indexType() uint32 {
+	if gl.HasExtension("GL_OES_element_index_uint") {
+		return gl.GL_UNSIGNED_INT
+	}
+	return gl.GL_UNSIGNED_SHORT
+}
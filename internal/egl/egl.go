@@ -0,0 +1,202 @@
+// +build egl
+
+// Package egl creates headless OpenGL contexts with EGL, for tests and
+// benchmarks. It requires the EGL library and headers, and is only built with
+// the egl build tag:
+//
+//	go test -tags egl ./...
+//
+package egl
+
+/*
+#cgo linux freebsd pkg-config: egl
+
+#include <stdlib.h>
+#include <EGL/egl.h>
+#include <EGL/eglext.h>
+
+#ifndef EGL_PLATFORM_SURFACELESS_MESA
+#define EGL_PLATFORM_SURFACELESS_MESA 0x31DD
+#endif
+
+static EGLDisplay dpy = EGL_NO_DISPLAY;
+static EGLContext ctx = EGL_NO_CONTEXT;
+static EGLSurface surf = EGL_NO_SURFACE;
+
+static EGLDisplay getDisplay() {
+	PFNEGLGETPLATFORMDISPLAYEXTPROC getPlatformDisplay =
+		(PFNEGLGETPLATFORMDISPLAYEXTPROC)eglGetProcAddress("eglGetPlatformDisplayEXT");
+	if (getPlatformDisplay != NULL) {
+		EGLDisplay d = getPlatformDisplay(EGL_PLATFORM_SURFACELESS_MESA, EGL_DEFAULT_DISPLAY, NULL);
+		if (d != EGL_NO_DISPLAY) {
+			return d;
+		}
+	}
+	return eglGetDisplay(EGL_DEFAULT_DISPLAY);
+}
+
+static int initContext(int gles, int major, int minor, int core, int width, int height) {
+	EGLint n;
+	EGLConfig cfg;
+	EGLint cfgAttr[] = {
+		EGL_SURFACE_TYPE, EGL_PBUFFER_BIT,
+		EGL_RENDERABLE_TYPE, gles ? (major >= 3 ? EGL_OPENGL_ES3_BIT : EGL_OPENGL_ES2_BIT) : EGL_OPENGL_BIT,
+		EGL_RED_SIZE, 8, EGL_GREEN_SIZE, 8, EGL_BLUE_SIZE, 8, EGL_ALPHA_SIZE, 8,
+		EGL_DEPTH_SIZE, 24, EGL_STENCIL_SIZE, 8,
+		EGL_NONE,
+	};
+	EGLint surfAttr[] = {EGL_WIDTH, width, EGL_HEIGHT, height, EGL_NONE};
+	EGLint ctxAttr[] = {
+		EGL_CONTEXT_MAJOR_VERSION, major,
+		EGL_CONTEXT_MINOR_VERSION, minor,
+		EGL_CONTEXT_OPENGL_PROFILE_MASK,
+		core ? EGL_CONTEXT_OPENGL_CORE_PROFILE_BIT : EGL_CONTEXT_OPENGL_COMPATIBILITY_PROFILE_BIT,
+		EGL_NONE,
+	};
+	if (gles) {
+		ctxAttr[4] = EGL_NONE;
+	}
+
+	dpy = getDisplay();
+	if (dpy == EGL_NO_DISPLAY || !eglInitialize(dpy, NULL, NULL)) {
+		return 1;
+	}
+	if (!eglBindAPI(gles ? EGL_OPENGL_ES_API : EGL_OPENGL_API)) {
+		return 2;
+	}
+	if (!eglChooseConfig(dpy, cfgAttr, &cfg, 1, &n) || n < 1) {
+		return 3;
+	}
+	surf = eglCreatePbufferSurface(dpy, cfg, surfAttr);
+	if (surf == EGL_NO_SURFACE) {
+		return 4;
+	}
+	ctx = eglCreateContext(dpy, cfg, EGL_NO_CONTEXT, ctxAttr);
+	if (ctx == EGL_NO_CONTEXT) {
+		return 5;
+	}
+	if (!eglMakeCurrent(dpy, surf, surf, ctx)) {
+		return 6;
+	}
+	return 0;
+}
+
+static int makeCurrent() {
+	return eglMakeCurrent(dpy, surf, surf, ctx) ? 0 : 1;
+}
+
+static void release() {
+	eglMakeCurrent(dpy, EGL_NO_SURFACE, EGL_NO_SURFACE, EGL_NO_CONTEXT);
+}
+
+static void destroyContext() {
+	eglMakeCurrent(dpy, EGL_NO_SURFACE, EGL_NO_SURFACE, EGL_NO_CONTEXT);
+	eglDestroyContext(dpy, ctx);
+	eglDestroySurface(dpy, surf);
+	eglTerminate(dpy);
+	ctx = EGL_NO_CONTEXT;
+	surf = EGL_NO_SURFACE;
+	dpy = EGL_NO_DISPLAY;
+}
+
+static void *getProcAddress(const char *name) {
+	return eglGetProcAddress(name);
+}
+*/
+import "C"
+
+import (
+	"runtime"
+	"sync"
+	"unsafe"
+
+	"github.com/db47h/grog/gl"
+	"golang.org/x/xerrors"
+)
+
+// Init creates an OpenGL context with a width x height pbuffer as its default
+// frame buffer, makes it current on the calling thread and loads OpenGL
+// functions, including extension functions. The context has the API and
+// version of gl.APIVersion, with a core profile if core is true. It must be
+// called from a locked OS thread.
+//
+func Init(width, height int, core bool) error {
+	v := gl.APIVersion()
+	var gles, coreProfile C.int
+	if v.API == gl.OpenGLES {
+		gles = 1
+	} else if core || gl.CoreProfile {
+		v.Major, v.Minor = 3, 3
+		coreProfile = 1
+	}
+	if st := C.initContext(gles, C.int(v.Major), C.int(v.Minor), coreProfile, C.int(width), C.int(height)); st != 0 {
+		return xerrors.Errorf("EGL context creation failed at step %d: error 0x%x", int(st), int(C.eglGetError()))
+	}
+	gl.InitGo(getProcAddress)
+	gl.InitExtGo(getProcAddress)
+	gl.Viewport(0, 0, int32(width), int32(height))
+	return nil
+}
+
+// MakeCurrent makes the context created by Init current on the calling thread.
+// The context must not be current on any other thread; see Release.
+//
+func MakeCurrent() error {
+	if C.makeCurrent() != 0 {
+		return xerrors.Errorf("eglMakeCurrent failed: error 0x%x", int(C.eglGetError()))
+	}
+	return nil
+}
+
+// Release releases the current context of the calling thread, so that it can be
+// made current on another thread.
+//
+func Release() {
+	C.release()
+}
+
+// Terminate destroys the context created by Init.
+//
+func Terminate() {
+	C.destroyContext()
+}
+
+// Size of the default frame buffer of the context shared by tests.
+//
+const Width, Height = 1280, 720
+
+var (
+	shared    sync.Once
+	sharedErr error
+)
+
+// Acquire makes a context shared by tests current on the calling goroutine,
+// creating it on first use with a Width x Height default frame buffer. It locks
+// the goroutine to its OS thread until the returned function is called, which
+// releases the context.
+//
+func Acquire() (release func(), err error) {
+	runtime.LockOSThread()
+	shared.Do(func() {
+		if sharedErr = Init(Width, Height, false); sharedErr == nil {
+			Release()
+		}
+	})
+	if err = sharedErr; err == nil {
+		err = MakeCurrent()
+	}
+	if err != nil {
+		runtime.UnlockOSThread()
+		return nil, err
+	}
+	return func() {
+		Release()
+		runtime.UnlockOSThread()
+	}, nil
+}
+
+func getProcAddress(name string) unsafe.Pointer {
+	cs := C.CString(name)
+	defer C.free(unsafe.Pointer(cs))
+	return C.getProcAddress(cs)
+}
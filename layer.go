@@ -0,0 +1,98 @@
+package grog
+
+import (
+	"image/color"
+	"sort"
+)
+
+type primKind int
+
+const (
+	primSprite primKind = iota
+	primTriangles
+	primQuad
+	primMesh
+)
+
+// A layerCmd is a draw call queued for sorting.
+//
+type layerCmd struct {
+	kind  primKind
+	layer int
+	id    uint32 // texture ID
+	d     Drawable
+	dp    Point
+	scale Point
+	rot   float32
+	c     color.Color
+	v, nv int // vertices, in layerQueue.v
+	i, ni int // indices, in layerQueue.i
+}
+
+// A layerQueue queues draw calls of a batch in sorted mode.
+//
+type layerQueue struct {
+	sorted bool
+	cmds   []layerCmd
+	v      []Vertex
+	i      []uint16
+}
+
+// layerTarget is implemented by batches that use a layerQueue.
+//
+type layerTarget interface {
+	draw(d Drawable, dp, scale Point, rot float32, c color.Color)
+	triangles(d Drawable, v []Vertex)
+	quad(d Drawable, q *[4]Vertex)
+	mesh(d Drawable, v []Vertex, indices []uint16)
+}
+
+func (q *layerQueue) push(layer int, d Drawable, dp, scale Point, rot float32, c color.Color) {
+	q.cmds = append(q.cmds, layerCmd{layer: layer, id: d.NativeID(), d: d, dp: dp, scale: scale, rot: rot, c: c})
+}
+
+// pushVertices queues a DrawTriangles, DrawQuad or DrawMesh call. Vertices and
+// indices are copied.
+//
+func (q *layerQueue) pushVertices(kind primKind, layer int, d Drawable, v []Vertex, indices []uint16) {
+	q.cmds = append(q.cmds, layerCmd{kind: kind, layer: layer, id: d.NativeID(), d: d, v: len(q.v), nv: len(v), i: len(q.i), ni: len(indices)})
+	q.v = append(q.v, v...)
+	q.i = append(q.i, indices...)
+}
+
+// drain sorts queued draw calls by layer then by texture, and passes them to
+// t in that order. The sort is stable, so draw calls with the same layer and
+// texture are drawn in call order.
+//
+func (q *layerQueue) drain(t layerTarget) {
+	if len(q.cmds) == 0 {
+		return
+	}
+	cmds := q.cmds
+	sort.SliceStable(cmds, func(i, j int) bool {
+		if cmds[i].layer != cmds[j].layer {
+			return cmds[i].layer < cmds[j].layer
+		}
+		return cmds[i].id < cmds[j].id
+	})
+	for i := range cmds {
+		c := &cmds[i]
+		v := q.v[c.v : c.v+c.nv]
+		switch c.kind {
+		case primSprite:
+			t.draw(c.d, c.dp, c.scale, c.rot, c.c)
+		case primTriangles:
+			t.triangles(c.d, v)
+		case primQuad:
+			var qv [4]Vertex
+			copy(qv[:], v)
+			t.quad(c.d, &qv)
+		case primMesh:
+			t.mesh(c.d, v, q.i[c.i:c.i+c.ni])
+		}
+		*c = layerCmd{}
+	}
+	q.cmds = cmds[:0]
+	q.v = q.v[:0]
+	q.i = q.i[:0]
+}
@@ -0,0 +1,80 @@
+package grog
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"reflect"
+	"testing"
+)
+
+var (
+	_ LayeredMeshRenderer = (*batch)(nil)
+	_ LayeredMeshRenderer = (*concurrentBatch)(nil)
+)
+
+// A layerLog is a layerTarget that logs the draw calls of a layerQueue.
+//
+type layerLog []string
+
+func (l *layerLog) draw(d Drawable, dp, scale Point, rot float32, c color.Color) {
+	*l = append(*l, fmt.Sprintf("sprite %v %v", d, dp))
+}
+
+func (l *layerLog) triangles(d Drawable, v []Vertex) {
+	*l = append(*l, fmt.Sprintf("triangles %v %v", d, v[0].Pos))
+}
+
+func (l *layerLog) quad(d Drawable, q *[4]Vertex) {
+	*l = append(*l, fmt.Sprintf("quad %v %v", d, q[0].Pos))
+}
+
+func (l *layerLog) mesh(d Drawable, v []Vertex, indices []uint16) {
+	*l = append(*l, fmt.Sprintf("mesh %v %v %v", d, v[0].Pos, indices))
+}
+
+func TestLayerQueue_drain(t *testing.T) {
+	var (
+		q    layerQueue
+		got  layerLog
+		t1   = &testDrawable{1, image.Pt(1, 1)}
+		t2   = &testDrawable{2, image.Pt(1, 1)}
+		tri  = []Vertex{{Pos: Pt(7, 0)}, {}, {}}
+		quad = [4]Vertex{{Pos: Pt(8, 0)}}
+		mesh = []Vertex{{Pos: Pt(9, 0)}, {}, {}}
+	)
+	q.push(1, t2, Pt(0, 0), Pt(1, 1), 0, nil)
+	q.push(0, t2, Pt(1, 0), Pt(1, 1), 0, nil)
+	q.push(1, t1, Pt(2, 0), Pt(1, 1), 0, nil)
+	q.pushVertices(primTriangles, 1, t2, tri, nil)
+	q.push(-1, t2, Pt(3, 0), Pt(1, 1), 0, nil)
+	q.pushVertices(primQuad, 0, t1, quad[:], nil)
+	q.push(1, t1, Pt(4, 0), Pt(1, 1), 0, nil)
+	q.pushVertices(primMesh, 0, t2, mesh, []uint16{2, 1, 0})
+	q.push(0, t2, Pt(5, 0), Pt(1, 1), 0, nil)
+	// vertices are copied
+	tri[0].Pos, quad[0].Pos, mesh[0].Pos = Point{}, Point{}, Point{}
+
+	q.drain(&got)
+	want := layerLog{
+		"sprite tex2 (3.00,0.00)",
+		"quad tex1 (8.00,0.00)",
+		"sprite tex2 (1.00,0.00)",
+		"mesh tex2 (9.00,0.00) [2 1 0]",
+		"sprite tex2 (5.00,0.00)",
+		"sprite tex1 (2.00,0.00)",
+		"sprite tex1 (4.00,0.00)",
+		"sprite tex2 (0.00,0.00)",
+		"triangles tex2 (7.00,0.00)",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("drain:\ngot  %q\nwant %q", got, want)
+	}
+
+	// the queue is empty after drain
+	got = got[:0]
+	q.drain(&got)
+	if len(got) != 0 || len(q.v) != 0 || len(q.i) != 0 {
+		t.Fatalf("drain of an empty queue: got %q, %d vertices, %d indices", got, len(q.v), len(q.i))
+	}
+}
@@ -0,0 +1,312 @@
+package grog
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/db47h/grog/gl"
+	"golang.org/x/xerrors"
+)
+
+// texture unit indices for the uTextures sampler array.
+//
+var textureUnitIndices [maxTextureUnits]int32
+
+func init() {
+	for i := range textureUnitIndices {
+		textureUnitIndices[i] = int32(i)
+	}
+}
+
+// DefaultShaders returns the source code of the default vertex and fragment
+// shaders for the given number of texture units. They are a good starting point
+// for writing custom shaders.
+//
+// The shaders are written for the current context: GLSL 1.50 or 3.30 for
+// OpenGL core profile contexts, where attributes and varyings are declared
+// with in and out, GLSL 1.30 for other OpenGL contexts and GLSL ES 1.00 for
+// OpenGLES.
+//
+func DefaultShaders(units int) (vertex, fragment []byte) {
+	d := currentDialect()
+	return vertexShaderSource(d), fragmentShader(d, units)
+}
+
+// vertexShaderSource returns the source of the default vertex shader in
+// dialect d.
+//
+func vertexShaderSource(d glslDialect) []byte {
+	if d == glslCompat {
+		return append([]byte(nil), vertexShader...)
+	}
+	return []byte(d.version() + vertexShaderCore)
+}
+
+// fragmentShader returns the source of a fragment shader in dialect d sampling
+// from the given number of texture units.
+//
+func fragmentShader(d glslDialect, units int) []byte {
+	return fragmentShaderWith(d, units, fragmentVariant{})
+}
+
+// A fragmentVariant holds the changes made to the default fragment shader by
+// built-in materials.
+//
+type fragmentVariant struct {
+	decl   string // declarations
+	post   string // code inserted after texColor is set
+	noWrap bool   // no vWrap input: texture coordinates never wrap around
+}
+
+// fragmentShaderWith is like fragmentShader, with the changes of variant v.
+// Unless v.noWrap is set, texture coordinates wrap around within the vWrap
+// region when it is not zero (see Vertex).
+//
+func fragmentShaderWith(d glslDialect, units int, v fragmentVariant) []byte {
+	var (
+		sel    strings.Builder
+		decl   = v.decl
+		coords = "vTexCoords"
+	)
+	if !v.noWrap {
+		in := "in"
+		if d == glslCompat {
+			in = "varying"
+		}
+		decl = in + " vec4 vWrap;\n" + decl
+		sel.WriteString("    vec2 texCoords = vWrap == vec4(0.0) ? vTexCoords : mix(vWrap.xw, vWrap.zy, fract(vTexCoords));\n")
+		coords = "texCoords"
+	}
+	for i := 0; i < units-1; i++ {
+		if i > 0 {
+			sel.WriteString("    else ")
+		} else {
+			sel.WriteString("    ")
+		}
+		fmt.Fprintf(&sel, "if (vTexIndex < %d.5) texColor = %s(uTextures[%d], %s);\n", i, d.texture(), i, coords)
+	}
+	if units > 1 {
+		sel.WriteString("    else ")
+	} else {
+		sel.WriteString("    ")
+	}
+	fmt.Fprintf(&sel, "texColor = %s(uTextures[%d], %s);", d.texture(), units-1, coords)
+	sel.WriteString(v.post)
+	if d == glslCompat {
+		return []byte(fmt.Sprintf(fragmentShaderFmt, units, decl, sel.String()))
+	}
+	return []byte(fmt.Sprintf(fragmentShaderCoreFmt, d.version(), d.fragOutput(), units, decl, sel.String()))
+}
+
+func loadShaders(units int) (gl.Program, error) {
+	d := currentDialect()
+	return compileProgram(vertexShaderSource(d), fragmentShader(d, units))
+}
+
+// compileProgram compiles and links a program from vertex and fragment shader
+// sources.
+//
+func compileProgram(vertexSrc, fragmentSrc []byte) (gl.Program, error) {
+	var (
+		vertex, frag gl.Shader
+		err          error
+	)
+	vertex, err = gl.NewShader(gl.GL_VERTEX_SHADER, vertexSrc)
+	if err != nil {
+		return 0, err
+	}
+	defer vertex.Delete()
+	frag, err = gl.NewShader(gl.GL_FRAGMENT_SHADER, fragmentSrc)
+	if err != nil {
+		return 0, err
+	}
+	defer frag.Delete()
+
+	program, err := gl.NewProgram(vertex, frag)
+	if err != nil {
+		return 0, err
+	}
+
+	return program, nil
+}
+
+// textureUnits returns the number of texture units to use in a batch.
+//
+func textureUnits() int {
+	var n int32
+	gl.GetIntegerv(gl.GL_MAX_TEXTURE_IMAGE_UNITS, &n)
+	if n > maxTextureUnits {
+		n = maxTextureUnits
+	}
+	if n < 1 {
+		n = 1
+	}
+	return int(n)
+}
+
+// A Material is a shader program used by batches to draw quads.
+//
+// The program must use the same vertex attributes as the default vertex shader
+// (see DefaultShaders):
+//
+//	attribute vec4 aPos;       // position in xy, texture coordinates in zw
+//	attribute vec4 aColor;     // alpha premultiplied vertex color
+//	attribute float aTexIndex; // index in uTextures of the texture to sample
+//	attribute vec4 aWrap;      // wrap region, see Vertex
+//
+// As well as the following uniforms:
+//
+//	uniform mat4 uProjection;
+//	uniform sampler2D uTextures[N];
+//
+// The size N of the uTextures array determines how many textures can be
+// used in a single draw call. It can be as low as 1, in which case aTexIndex
+// is always 0.
+//
+// With OpenGL core profile contexts, attributes are declared with the in
+// qualifier instead of attribute.
+//
+// Only aPos and uProjection are mandatory. GLSL compilers remove unused
+// attributes and uniforms, so a program that does not use vertex colors for
+// example will not have an aColor attribute. Programs without aWrap ignore
+// the Wrap region of vertices, and draw FillWrapped fills incorrectly.
+//
+type Material struct {
+	program gl.Program
+	units   int
+	attr    struct {
+		pos      uint32
+		color    uint32
+		texIndex uint32
+		wrap     uint32
+	}
+	uniform struct {
+		cam int32
+		tex int32
+	}
+	locs map[string]int32
+}
+
+// noAttrib is the location of unused attributes.
+//
+const noAttrib = ^uint32(0)
+
+// NewMaterial returns a new Material for the given program. It returns an
+// error if the program does not have the required attributes and uniforms.
+//
+func NewMaterial(p gl.Program) (*Material, error) {
+	var (
+		m   = &Material{program: p, locs: make(map[string]int32)}
+		err error
+	)
+	m.attr.pos, err = p.AttribLocation("aPos")
+	if err != nil {
+		return nil, err
+	}
+	m.attr.color, _ = p.AttribLocation("aColor")
+	m.attr.texIndex, _ = p.AttribLocation("aTexIndex")
+	m.attr.wrap, _ = p.AttribLocation("aWrap")
+	m.uniform.cam = p.UniformLocation("uProjection")
+	if m.uniform.cam < 0 {
+		return nil, xerrors.New("unknown uniform uProjection")
+	}
+	m.uniform.tex = p.UniformLocation("uTextures")
+	if m.uniform.tex >= 0 && m.attr.texIndex != noAttrib {
+		max := textureUnits()
+		for m.units < max && p.UniformLocation(fmt.Sprintf("uTextures[%d]", m.units)) >= 0 {
+			m.units++
+		}
+	}
+	if m.units == 0 {
+		m.units = 1
+	}
+	return m, nil
+}
+
+func newDefaultMaterial() (*Material, error) {
+	p, err := loadShaders(textureUnits())
+	if err != nil {
+		return nil, err
+	}
+	return newProgramMaterial(p)
+}
+
+// newMaskMaterial returns the material used to draw clip masks. It is the
+// default material, with fragments of alpha lower than 0.5 discarded.
+//
+func newMaskMaterial() (*Material, error) {
+	var (
+		d     = currentDialect()
+		units = textureUnits()
+	)
+	p, err := compileProgram(vertexShaderSource(d),
+		fragmentShaderWith(d, units, fragmentVariant{post: "\n    if (vTexColor.a * texColor.a < 0.5) discard;"}))
+	if err != nil {
+		return nil, err
+	}
+	return newProgramMaterial(p)
+}
+
+// newProgramMaterial returns a new material for p, and deletes p on error.
+//
+func newProgramMaterial(p gl.Program) (*Material, error) {
+	m, err := NewMaterial(p)
+	if err != nil {
+		p.Delete()
+		return nil, err
+	}
+	return m, nil
+}
+
+// Program returns the material's shader program.
+//
+func (m *Material) Program() gl.Program {
+	return m.program
+}
+
+// Delete deletes the material's shader program.
+//
+func (m *Material) Delete() {
+	m.program.Delete()
+}
+
+// location returns the location of the named uniform.
+//
+func (m *Material) location(name string) int32 {
+	loc, ok := m.locs[name]
+	if !ok {
+		loc = m.program.UniformLocation(name)
+		m.locs[name] = loc
+	}
+	return loc
+}
+
+// use makes m the current program and sets its uniforms.
+//
+func (m *Material) use(proj *[16]float32) {
+	m.program.Use()
+	if m.uniform.tex >= 0 {
+		gl.Uniform1iv(m.uniform.tex, int32(m.units), &textureUnitIndices[0])
+	}
+	gl.UniformMatrix4fv(m.uniform.cam, 1, gl.GL_FALSE, &proj[0])
+}
+
+// setAttribs sets up m's vertex attributes for the currently bound vertex
+// buffer.
+//
+func (m *Material) setAttribs() {
+	gl.EnableVertexAttribArray(m.attr.pos)
+	gl.VertexAttribOffset(m.attr.pos, 4, gl.GL_FLOAT, gl.GL_FALSE, vertexSize, 0)
+	if m.attr.color != noAttrib {
+		gl.EnableVertexAttribArray(m.attr.color)
+		gl.VertexAttribOffset(m.attr.color, 4, gl.GL_UNSIGNED_BYTE, gl.GL_TRUE, vertexSize, 4*4)
+	}
+	if m.attr.texIndex != noAttrib {
+		gl.EnableVertexAttribArray(m.attr.texIndex)
+		gl.VertexAttribOffset(m.attr.texIndex, 1, gl.GL_UNSIGNED_BYTE, gl.GL_FALSE, vertexSize, 4*4+4)
+	}
+	if m.attr.wrap != noAttrib {
+		gl.EnableVertexAttribArray(m.attr.wrap)
+		gl.VertexAttribOffset(m.attr.wrap, 4, gl.GL_UNSIGNED_SHORT, gl.GL_TRUE, vertexSize, 4*4+8)
+	}
+}
@@ -0,0 +1,326 @@
+package grog
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"golang.org/x/xerrors"
+)
+
+// Insets are the widths in pixels of the borders of a NinePatch.
+//
+type Insets struct {
+	Left, Top, Right, Bottom int
+}
+
+// PatchMode selects how the borders and center of a NinePatch fill their
+// target area.
+//
+type PatchMode int
+
+const (
+	PatchStretch PatchMode = iota // stretch to fill the area
+	PatchTile                     // repeat at their original size
+)
+
+// A NinePatch is a scalable image split in nine slices by its Insets: corners
+// are drawn as-is, top and bottom borders are resized horizontally, left and
+// right borders vertically, and the center in both directions.
+//
+// A NinePatch is drawn with the same origin and rotation semantics as a
+// Region: the origin, given in pixels from the top left corner of the patch at
+// its target size, is drawn at the given position, and the patch is rotated
+// around it.
+//
+type NinePatch struct {
+	// Border sets how top, bottom, left and right borders are resized.
+	Border PatchMode
+	// Center sets how the center is resized.
+	Center PatchMode
+
+	slices  [9]Region // top left, top, top right, left, center, right, bottom left, bottom, bottom right
+	insets  Insets
+	padding Insets
+	origin  image.Point
+}
+
+// NinePatch returns a NinePatch using the whole texture.
+//
+func (t *Texture) NinePatch(insets Insets, origin image.Point) *NinePatch {
+	return newNinePatch(t, image.Rect(0, 0, t.width, t.height), insets, origin)
+}
+
+// NinePatch returns a NinePatch using the region.
+//
+func (r *Region) NinePatch(insets Insets, origin image.Point) *NinePatch {
+	return newNinePatch(r.Texture, r.bounds, insets, origin)
+}
+
+func newNinePatch(t *Texture, b image.Rectangle, insets Insets, origin image.Point) *NinePatch {
+	var (
+		xs = [4]int{b.Min.X, b.Min.X + insets.Left, b.Max.X - insets.Right, b.Max.X}
+		ys = [4]int{b.Min.Y, b.Min.Y + insets.Top, b.Max.Y - insets.Bottom, b.Max.Y}
+		n  = &NinePatch{insets: insets, padding: insets, origin: origin}
+	)
+	for j := 0; j < 3; j++ {
+		for i := 0; i < 3; i++ {
+			n.slices[j*3+i] = Region{Texture: t, bounds: image.Rect(xs[i], ys[j], xs[i+1], ys[j+1])}
+		}
+	}
+	return n
+}
+
+// NinePatchFromImage creates a new texture and a NinePatch from an
+// Android-style .9.png image: a 1 pixel wide border around the image marks the
+// area to stretch with black pixels in its top and left lines, and the content
+// area with black pixels in its bottom and right lines. Only the outermost
+// black pixels of each line are used. Content markers are optional, and
+// default to the stretch area. Red pixels (optical bounds markers) are
+// ignored.
+//
+func NinePatchFromImage(src image.Image, origin image.Point, params ...TextureParameter) (*NinePatch, error) {
+	in, insets, padding, err := patchInsets(src)
+	if err != nil {
+		return nil, err
+	}
+	dst := image.NewRGBA(image.Rectangle{Max: in.Size()})
+	draw.Draw(dst, dst.Bounds(), src, in.Min, draw.Src)
+	n := TextureFromImage(dst, params...).NinePatch(insets, origin)
+	n.padding = padding
+	return n, nil
+}
+
+// patchInsets returns the bounds of the image within the markers of a .9.png
+// image, and the insets and padding set by the markers.
+//
+func patchInsets(src image.Image) (in image.Rectangle, insets, padding Insets, err error) {
+	b := src.Bounds()
+	if b.Dx() < 3 || b.Dy() < 3 {
+		return in, insets, padding, xerrors.New("nine-patch image too small")
+	}
+	in = b.Inset(1)
+	w, h := in.Dx(), in.Dy()
+	var ok bool
+	if insets.Left, insets.Right, ok, err = patchMarkers(src, image.Pt(in.Min.X, b.Min.Y), image.Pt(1, 0), w); err != nil || !ok {
+		return in, insets, padding, patchError("top", err)
+	}
+	if insets.Top, insets.Bottom, ok, err = patchMarkers(src, image.Pt(b.Min.X, in.Min.Y), image.Pt(0, 1), h); err != nil || !ok {
+		return in, insets, padding, patchError("left", err)
+	}
+	padding = insets
+	if l, r, ok, err := patchMarkers(src, image.Pt(in.Min.X, b.Max.Y-1), image.Pt(1, 0), w); err != nil {
+		return in, insets, padding, patchError("bottom", err)
+	} else if ok {
+		padding.Left, padding.Right = l, r
+	}
+	if t, bt, ok, err := patchMarkers(src, image.Pt(b.Max.X-1, in.Min.Y), image.Pt(0, 1), h); err != nil {
+		return in, insets, padding, patchError("right", err)
+	} else if ok {
+		padding.Top, padding.Bottom = t, bt
+	}
+	return in, insets, padding, nil
+}
+
+// patchMarkers scans n pixels of a border line of a .9.png image, starting at
+// p in direction d, and returns the number of pixels before the first black
+// pixel and after the last one. ok is false if there are no black pixels.
+//
+func patchMarkers(src image.Image, p, d image.Point, n int) (lo, hi int, ok bool, err error) {
+	first, last := -1, -1
+	for i := 0; i < n; i, p = i+1, p.Add(d) {
+		r, g, b, a := src.At(p.X, p.Y).RGBA()
+		switch {
+		case a == 0:
+		case a == 0xffff && r == 0 && g == 0 && b == 0:
+			if first < 0 {
+				first = i
+			}
+			last = i
+		case a == 0xffff && r == 0xffff && g == 0 && b == 0:
+			// optical bounds
+		default:
+			return 0, 0, false, xerrors.Errorf("invalid marker color at %v", p)
+		}
+	}
+	if first < 0 {
+		return 0, 0, false, nil
+	}
+	return first, n - last - 1, true, nil
+}
+
+func patchError(line string, err error) error {
+	if err == nil {
+		return xerrors.Errorf("nine-patch image: no markers in %s line", line)
+	}
+	return xerrors.Errorf("nine-patch image: %s line: %w", line, err)
+}
+
+// Insets returns the insets of the patch.
+//
+func (n *NinePatch) Insets() Insets {
+	return n.insets
+}
+
+// Padding returns the padding of the content area. It is the same as the
+// insets, unless set by the content markers of a .9.png image.
+//
+func (n *NinePatch) Padding() Insets {
+	return n.padding
+}
+
+// Content returns the content area of the patch drawn at the given size, in
+// pixels from its top left corner.
+//
+func (n *NinePatch) Content(size image.Point) image.Rectangle {
+	p := n.padding
+	return image.Rect(p.Left, p.Top, size.X-p.Right, size.Y-p.Bottom)
+}
+
+// Origin returns the point of origin of the patch.
+//
+func (n *NinePatch) Origin() image.Point {
+	return n.origin
+}
+
+// MinSize returns the smallest size at which borders are not shrunk.
+//
+func (n *NinePatch) MinSize() image.Point {
+	return image.Pt(n.insets.Left+n.insets.Right, n.insets.Top+n.insets.Bottom)
+}
+
+// Draw draws the patch resized to size pixels, then scaled by scale. Borders
+// are only resized along their length, except if size is smaller than
+// MinSize, in which case they are shrunk proportionally.
+//
+func (n *NinePatch) Draw(r Renderer, dp Point, size image.Point, scale Point, rot float32, c color.Color) {
+	var (
+		xs = patchEdges(size.X, n.insets.Left, n.insets.Right)
+		ys = patchEdges(size.Y, n.insets.Top, n.insets.Bottom)
+		p  = patchPainter{r: r, dp: dp, scale: scale, rot: rot, c: c, o: PtPt(n.origin)}
+	)
+	if rot != 0 {
+		p.sin, p.cos = float32(math.Sin(float64(rot))), float32(math.Cos(float64(rot)))
+	} else {
+		p.cos = 1
+	}
+	for j := 0; j < 3; j++ {
+		for i := 0; i < 3; i++ {
+			var (
+				s    = &n.slices[j*3+i]
+				x, y = xs[i], ys[j]
+				w, h = xs[i+1] - x, ys[j+1] - y
+				mode = n.Border
+			)
+			switch {
+			case i == 1 && j == 1:
+				mode = n.Center
+			case i != 1 && j != 1:
+				mode = PatchStretch // corners
+			}
+			if mode == PatchTile {
+				p.tile(s, x, y, w, h)
+			} else {
+				p.draw(s, x, y, w, h)
+			}
+		}
+	}
+}
+
+// patchEdges returns the positions of the edges of the slices of a nine-patch
+// along one axis.
+//
+func patchEdges(size, lo, hi int) [4]float32 {
+	if size <= 0 {
+		return [4]float32{}
+	}
+	s, l, h := float32(size), float32(lo), float32(hi)
+	if lo+hi > size {
+		k := s / (l + h)
+		return [4]float32{0, l * k, l * k, s}
+	}
+	return [4]float32{0, l, s - h, s}
+}
+
+// A patchPainter draws the slices of a NinePatch.
+//
+type patchPainter struct {
+	r        Renderer
+	dp       Point
+	scale    Point
+	rot      float32
+	sin, cos float32
+	c        color.Color
+	o        Point // origin of the patch
+}
+
+// draw draws slice s resized to w×h pixels at x, y in the patch.
+//
+func (p *patchPainter) draw(s *Region, x, y, w, h float32) {
+	sz := s.Size()
+	if w <= 0 || h <= 0 || sz.X == 0 || sz.Y == 0 {
+		return
+	}
+	p.r.Draw(s, p.pos(x, y),
+		Point{w / float32(sz.X) * p.scale.X, h / float32(sz.Y) * p.scale.Y},
+		p.rot, p.c)
+}
+
+// pos returns the position of the point x, y of the patch.
+//
+func (p *patchPainter) pos(x, y float32) Point {
+	dx, dy := (x-p.o.X)*p.scale.X, (y-p.o.Y)*p.scale.Y
+	return Point{p.dp.X + p.cos*dx - p.sin*dy, p.dp.Y + p.sin*dx + p.cos*dy}
+}
+
+// tile fills the w×h pixels area at x, y in the patch with copies of slice s.
+// Copies on the right and bottom edges of the area are cut. If they are cut
+// at a fraction of a texel and the renderer can draw quads, their UV
+// coordinates are clipped to the exact fraction of s, otherwise they are cut
+// to whole texels.
+//
+func (p *patchPainter) tile(s *Region, x, y, w, h float32) {
+	sz := s.Size()
+	if w <= 0 || h <= 0 || sz.X == 0 || sz.Y == 0 {
+		return
+	}
+	tw, th := float32(sz.X), float32(sz.Y)
+	qd, quads := p.r.(meshDrawer)
+	for ty := float32(0); ty < h; ty += th {
+		for tx := float32(0); tx < w; tx += tw {
+			cw, ch := minf(tw, w-tx), minf(th, h-ty)
+			switch {
+			case cw == tw && ch == th:
+				p.draw(s, x+tx, y+ty, cw, ch)
+			case quads && (cw != floorf(cw) || ch != floorf(ch)):
+				p.drawPart(qd, s, x+tx, y+ty, cw, ch)
+			default:
+				b := s.bounds
+				t := &Region{Texture: s.Texture, bounds: image.Rect(b.Min.X, b.Min.Y,
+					b.Min.X+int(math.Ceil(float64(cw))), b.Min.Y+int(math.Ceil(float64(ch))))}
+				p.draw(t, x+tx, y+ty, cw, ch)
+			}
+		}
+	}
+}
+
+// drawPart draws the top left w×h texels of slice s at x, y in the patch.
+//
+func (p *patchPainter) drawPart(r meshDrawer, s *Region, x, y, w, h float32) {
+	var (
+		sz     = s.Size()
+		uv     = s.UV()
+		u1     = uv[0] + w/float32(sz.X)*(uv[2]-uv[0])
+		v1     = uv[3] + h/float32(sz.Y)*(uv[1]-uv[3])
+		gc     = vertexColor(p.c)
+		x1, y1 = x + w, y + h
+		q      = [4]Vertex{
+			{Pos: p.pos(x, y), UV: Point{uv[0], uv[3]}, Color: gc},
+			{Pos: p.pos(x1, y), UV: Point{u1, uv[3]}, Color: gc},
+			{Pos: p.pos(x1, y1), UV: Point{u1, v1}, Color: gc},
+			{Pos: p.pos(x, y1), UV: Point{uv[0], v1}, Color: gc},
+		}
+	)
+	r.DrawQuad(s, &q)
+}
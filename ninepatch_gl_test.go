@@ -0,0 +1,50 @@
+// +build egl
+
+package grog
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestNinePatchFromImage(t *testing.T) {
+	defer glContext(t)()
+	src := patchImage(image.Pt(3, 5),
+		"..KK..",
+		".####.",
+		"K####.",
+		"K####K",
+		".####.",
+		".K....",
+	)
+	n, err := NinePatchFromImage(src, image.Pt(2, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer n.slices[0].Delete()
+	if want := (Insets{1, 1, 1, 1}); n.Insets() != want {
+		t.Errorf("Insets() = %+v, want %+v", n.Insets(), want)
+	}
+	if want := (Insets{0, 2, 3, 1}); n.Padding() != want {
+		t.Errorf("Padding() = %+v, want %+v", n.Padding(), want)
+	}
+	if n.Origin() != image.Pt(2, 2) {
+		t.Errorf("Origin() = %v, want (2,2)", n.Origin())
+	}
+	img := n.slices[0].Texture.Image()
+	if img.Rect != image.Rect(0, 0, 4, 4) {
+		t.Fatalf("texture bounds = %v, want (0,0)-(4,4)", img.Rect)
+	}
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			if c, want := img.RGBAAt(x, y), color.RGBAModel.Convert(src.At(x+4, y+6)); c != want {
+				t.Fatalf("texture pixel (%d, %d) = %v, want %v", x, y, c, want)
+			}
+		}
+	}
+
+	if _, err = NinePatchFromImage(patchImage(image.ZP, "...", ".#.", "..."), image.ZP); err == nil {
+		t.Error("no error for an image without markers")
+	}
+}
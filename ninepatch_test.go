@@ -0,0 +1,245 @@
+package grog
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"strings"
+	"testing"
+)
+
+// patchImage returns an image built from rows of pixels: '.' is transparent,
+// 'K' black, 'R' red, 'g' semi-transparent black, 'W' white and any other
+// character opaque gray. The top left pixel is at org.
+//
+func patchImage(org image.Point, rows ...string) *image.NRGBA {
+	img := image.NewNRGBA(image.Rectangle{Min: org, Max: org.Add(image.Pt(len(rows[0]), len(rows)))})
+	for y, row := range rows {
+		for x, c := range row {
+			var col color.NRGBA
+			switch c {
+			case '.':
+			case 'K':
+				col = color.NRGBA{A: 255}
+			case 'R':
+				col = color.NRGBA{R: 255, A: 255}
+			case 'g':
+				col = color.NRGBA{A: 128}
+			case 'W':
+				col = color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+			default:
+				col = color.NRGBA{R: 128, G: 128, B: 128, A: 255}
+			}
+			img.SetNRGBA(org.X+x, org.Y+y, col)
+		}
+	}
+	return img
+}
+
+func TestPatchInsets(t *testing.T) {
+	for _, tc := range []struct {
+		name            string
+		rows            []string
+		insets, padding Insets
+	}{
+		{"padding", []string{
+			"..KK..",
+			".####.",
+			"K####.",
+			"K####K",
+			".####.",
+			".K....",
+		}, Insets{1, 1, 1, 1}, Insets{0, 2, 3, 1}},
+		{"no padding", []string{
+			".KKK..",
+			"K####.",
+			"K####.",
+			".####.",
+			".####.",
+			"......",
+		}, Insets{0, 0, 1, 2}, Insets{0, 0, 1, 2}},
+		{"optical bounds", []string{
+			".RKKR.",
+			"R####.",
+			"K####K",
+			"R####R",
+			".####.",
+			".RK.R.",
+		}, Insets{1, 1, 1, 2}, Insets{1, 1, 2, 2}},
+		{"single pixels", []string{
+			".K.",
+			"K#.",
+			"...",
+		}, Insets{0, 0, 0, 0}, Insets{0, 0, 0, 0}},
+	} {
+		for _, org := range []image.Point{{}, {10, -20}} {
+			in, insets, padding, err := patchInsets(patchImage(org, tc.rows...))
+			if err != nil {
+				t.Errorf("%s: %v", tc.name, err)
+				continue
+			}
+			if want := image.Rect(1, 1, len(tc.rows[0])-1, len(tc.rows)-1).Add(org); in != want {
+				t.Errorf("%s: bounds = %v, want %v", tc.name, in, want)
+			}
+			if insets != tc.insets {
+				t.Errorf("%s: insets = %+v, want %+v", tc.name, insets, tc.insets)
+			}
+			if padding != tc.padding {
+				t.Errorf("%s: padding = %+v, want %+v", tc.name, padding, tc.padding)
+			}
+		}
+	}
+}
+
+func TestPatchInsets_errors(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		rows []string
+		err  string
+	}{
+		{"too small", []string{"..", ".."}, "too small"},
+		{"too short", []string{".K.", "K#."}, "too small"},
+		{"no top markers", []string{
+			"....",
+			"K##.",
+			".##.",
+			"....",
+		}, "no markers in top line"},
+		{"no left markers", []string{
+			".K..",
+			".##.",
+			".##.",
+			"....",
+		}, "no markers in left line"},
+		{"bad top color", []string{
+			".KW.",
+			"K##.",
+			".##.",
+			"....",
+		}, "top line: invalid marker color at (2,0)"},
+		{"bad left color", []string{
+			".K..",
+			"K##.",
+			"#.#.",
+			"....",
+		}, "left line: invalid marker color at (0,2)"},
+		{"translucent bottom marker", []string{
+			".K..",
+			"K##.",
+			".##.",
+			".g..",
+		}, "bottom line: invalid marker color at (1,3)"},
+		{"bad right color", []string{
+			".K..",
+			"K##W",
+			".##.",
+			"....",
+		}, "right line: invalid marker color at (3,1)"},
+	} {
+		_, _, _, err := patchInsets(patchImage(image.ZP, tc.rows...))
+		if err == nil {
+			t.Errorf("%s: no error", tc.name)
+			continue
+		}
+		if !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s: error %q does not contain %q", tc.name, err, tc.err)
+		}
+	}
+}
+
+// A patchLog records the sprites and quads drawn by a NinePatch.
+//
+type patchLog struct {
+	basicRecorder
+	sprites []*Region
+	pos     []Point
+	quads   [][4]Vertex
+}
+
+func (l *patchLog) Draw(d Drawable, dp, scale Point, rot float32, c color.Color) {
+	l.sprites = append(l.sprites, d.(*Region))
+	l.pos = append(l.pos, dp)
+}
+
+// A patchQuadLog also records quads.
+//
+type patchQuadLog struct {
+	patchLog
+}
+
+func (l *patchQuadLog) DrawQuad(d Drawable, q *[4]Vertex)                 { l.quads = append(l.quads, *q) }
+func (l *patchQuadLog) DrawMesh(d Drawable, v []Vertex, indices []uint16) {}
+
+func near(a, b Point) bool {
+	return math.Abs(float64(a.X-b.X)) < 1e-5 && math.Abs(float64(a.Y-b.Y)) < 1e-5
+}
+
+func TestNinePatch_tile(t *testing.T) {
+	// 4x4 slices in a 12x12 texture
+	n := (&Texture{width: 12, height: 12}).NinePatch(Insets{4, 4, 4, 4}, image.ZP)
+	n.Border = PatchTile
+
+	// 14x7: the top border is 6 pixels wide and 3.5 high
+	var l patchQuadLog
+	n.Draw(&l, Pt(0, 0), image.Pt(14, 7), Pt(1, 1), 0, nil)
+	var top [][4]Vertex
+	for _, q := range l.quads {
+		if q[0].Pos.Y == 0 {
+			top = append(top, q)
+		}
+	}
+	want := [][4]Point{
+		{{4, 0}, {8, 0}, {8, 3.5}, {4, 3.5}},
+		{{8, 0}, {10, 0}, {10, 3.5}, {8, 3.5}},
+	}
+	wantUV := [][4]Point{
+		{{4. / 12, 0}, {8. / 12, 0}, {8. / 12, 3.5 / 12}, {4. / 12, 3.5 / 12}},
+		{{4. / 12, 0}, {6. / 12, 0}, {6. / 12, 3.5 / 12}, {4. / 12, 3.5 / 12}},
+	}
+	if len(top) != len(want) {
+		t.Fatalf("got %d quads in the top border, want %d", len(top), len(want))
+	}
+	for i, q := range top {
+		for j := range q {
+			if !near(q[j].Pos, want[i][j]) || !near(q[j].UV, wantUV[i][j]) {
+				t.Errorf("quad %d vertex %d = %v %v, want %v %v", i, j, q[j].Pos, q[j].UV, want[i][j], wantUV[i][j])
+			}
+		}
+	}
+
+	// 14x16: the top border is 6 pixels wide, the last tile is cut at 2
+	// texels and drawn as a sprite.
+	l = patchQuadLog{}
+	n.Draw(&l, Pt(0, 0), image.Pt(14, 16), Pt(1, 1), 0, nil)
+	if len(l.quads) != 0 {
+		t.Errorf("got %d quads, want 0", len(l.quads))
+	}
+	var found bool
+	for i, s := range l.sprites {
+		if l.pos[i] == Pt(8, 0) {
+			found = true
+			if b := image.Rect(4, 0, 6, 4); s.bounds != b {
+				t.Errorf("cut tile bounds = %v, want %v", s.bounds, b)
+			}
+		}
+	}
+	if !found {
+		t.Error("cut tile not drawn")
+	}
+
+	// without quads, fractional tiles are cut to whole texels
+	var pl patchLog
+	n.Draw(&pl, Pt(0, 0), image.Pt(14, 7), Pt(1, 1), 0, nil)
+	found = false
+	for i, s := range pl.sprites {
+		if pl.pos[i] == Pt(8, 0) {
+			found = true
+			if b := image.Rect(4, 0, 6, 4); s.bounds != b {
+				t.Errorf("cut tile bounds = %v, want %v", s.bounds, b)
+			}
+		}
+	}
+	if !found {
+		t.Error("cut tile not drawn")
+	}
+}
@@ -0,0 +1,214 @@
+package post
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/db47h/grog"
+	"golang.org/x/xerrors"
+)
+
+// blurSrc is a 9 tap Gaussian blur along uDirection. Samples are spaced by
+// uDirection texels.
+//
+const blurSrc = `
+uniform vec2 uDirection;
+
+vec4 effect(vec2 uv) {
+    vec2 d = uDirection * uTexelSize;
+    vec4 c = texture2D(uSource, uv) * 0.227027;
+    c += (texture2D(uSource, uv + d) + texture2D(uSource, uv - d)) * 0.1945946;
+    c += (texture2D(uSource, uv + 2.0 * d) + texture2D(uSource, uv - 2.0 * d)) * 0.1216216;
+    c += (texture2D(uSource, uv + 3.0 * d) + texture2D(uSource, uv - 3.0 * d)) * 0.054054;
+    c += (texture2D(uSource, uv + 4.0 * d) + texture2D(uSource, uv - 4.0 * d)) * 0.016216;
+    return c;
+}
+`
+
+const brightSrc = `
+uniform float uThreshold;
+
+vec4 effect(vec2 uv) {
+    vec4 c = texture2D(uSource, uv);
+    float l = max(c.r, max(c.g, c.b));
+    return c * (max(l - uThreshold, 0.0) / max(l, 0.0001));
+}
+`
+
+const bloomSrc = `
+uniform float uIntensity;
+
+vec4 effect(vec2 uv) {
+    return texture2D(uInput, uv) + texture2D(uSource, uv) * uIntensity;
+}
+`
+
+const vignetteSrc = `
+uniform float uRadius;
+uniform float uSoftness;
+
+vec4 effect(vec2 uv) {
+    vec4 c = texture2D(uSource, uv);
+    float d = distance(uv, vec2(0.5)) * 1.4142136;
+    return vec4(c.rgb * (1.0 - smoothstep(uRadius, uRadius + uSoftness, d)), c.a);
+}
+`
+
+const colorGradeSrc = `
+uniform sampler2D uLUT;
+uniform float uLUTSize;
+
+vec4 effect(vec2 uv) {
+    vec4 c = texture2D(uSource, uv);
+    if (c.a == 0.0) {
+        return c;
+    }
+    vec3 rgb = clamp(c.rgb / c.a, 0.0, 1.0);
+    float n = uLUTSize;
+    float b = rgb.b * (n - 1.0);
+    float b0 = floor(b);
+    float b1 = min(b0 + 1.0, n - 1.0);
+    vec2 p = vec2((rgb.r * (n - 1.0) + 0.5) / (n * n), (rgb.g * (n - 1.0) + 0.5) / n);
+    vec3 c0 = texture2D(uLUT, p + vec2(b0 / n, 0.0)).rgb;
+    vec3 c1 = texture2D(uLUT, p + vec2(b1 / n, 0.0)).rgb;
+    return vec4(mix(c0, c1, b - b0) * c.a, c.a);
+}
+`
+
+const crtSrc = `
+uniform float uCurvature;
+uniform float uScanlines;
+
+vec4 effect(vec2 uv) {
+    vec2 p = uv * 2.0 - 1.0;
+    p *= 1.0 + p.yx * p.yx * uCurvature;
+    uv = p * 0.5 + 0.5;
+    if (uv.x < 0.0 || uv.x > 1.0 || uv.y < 0.0 || uv.y > 1.0) {
+        return vec4(0.0, 0.0, 0.0, 1.0);
+    }
+    vec4 c = texture2D(uSource, uv);
+    float s = 0.5 + 0.5 * sin(3.1415927 * uv.y / uTexelSize.y);
+    return vec4(c.rgb * (1.0 - uScanlines * s), c.a);
+}
+`
+
+// newEffect compiles an effect with one pass per shader source.
+//
+func newEffect(srcs ...string) (Effect, error) {
+	e := make(Effect, 0, len(srcs))
+	for _, src := range srcs {
+		p, err := NewShaderPass(src)
+		if err != nil {
+			e.Delete()
+			return nil, err
+		}
+		e = append(e, p)
+	}
+	return e, nil
+}
+
+// Blur returns a separable Gaussian blur effect of the given radius in pixels.
+// It has two passes, blurring horizontally, then vertically. Each pass takes
+// 9 samples spaced by radius/4 pixels along its uDirection uniform, which can
+// be changed to adjust the radius:
+//
+//	e[0].SetUniform("uDirection", r/4, 0)
+//	e[1].SetUniform("uDirection", 0, r/4)
+//
+func Blur(radius float32) (Effect, error) {
+	e, err := newEffect(blurSrc, blurSrc)
+	if err != nil {
+		return nil, err
+	}
+	e[0].SetUniform("uDirection", radius/4, 0)
+	e[1].SetUniform("uDirection", 0, radius/4)
+	return e, nil
+}
+
+// Bloom returns a bloom effect: colors brighter than threshold, in the range
+// [0, 1], are blurred with the given radius, multiplied by intensity and added
+// to the input. Its four passes are a bright pass with a uThreshold uniform,
+// the two passes of Blur, and the final composition with a uIntensity uniform.
+//
+func Bloom(threshold, intensity, radius float32) (Effect, error) {
+	e, err := newEffect(brightSrc, blurSrc, blurSrc, bloomSrc)
+	if err != nil {
+		return nil, err
+	}
+	e[0].SetUniform("uThreshold", threshold)
+	e[1].SetUniform("uDirection", radius/4, 0)
+	e[2].SetUniform("uDirection", 0, radius/4)
+	e[3].SetUniform("uIntensity", intensity)
+	return e, nil
+}
+
+// Vignette returns a vignette effect, darkening the image towards its edges.
+// Radius is the distance from the center, relative to the distance to the
+// corners, where darkening starts, and softness the distance over which it
+// fades to black. Softness must be greater than 0. Both are set by the
+// uRadius and uSoftness uniforms.
+//
+func Vignette(radius, softness float32) (Effect, error) {
+	e, err := newEffect(vignetteSrc)
+	if err != nil {
+		return nil, err
+	}
+	e[0].SetUniform("uRadius", radius)
+	e[0].SetUniform("uSoftness", softness)
+	return e, nil
+}
+
+// ColorGrade returns a color grading effect, which maps colors through a 3D
+// lookup table stored in a 2D texture: for a LUT of size N, the texture is N
+// squares of N×N pixels laid out horizontally, with red increasing to the
+// right within a square, green downwards, and blue from one square to the
+// next. See IdentityLUT.
+//
+// The LUT texture should use Linear filtering without mipmaps and ClampToEdge
+// wrapping. It is set by the uLUT sampler uniform, and its size by uLUTSize.
+//
+func ColorGrade(lut *grog.Texture) (Effect, error) {
+	sz := lut.Size()
+	if sz.Y < 2 || sz.X != sz.Y*sz.Y {
+		return nil, xerrors.Errorf("invalid color grading LUT size %v", sz)
+	}
+	e, err := newEffect(colorGradeSrc)
+	if err != nil {
+		return nil, err
+	}
+	e[0].SetTexture("uLUT", lut)
+	e[0].SetUniform("uLUTSize", float32(sz.Y))
+	return e, nil
+}
+
+// IdentityLUT returns a color grading LUT of size n that does not change
+// colors. It is a starting point for creating color grading LUTs in image
+// editors.
+//
+func IdentityLUT(n int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, n*n, n))
+	c := func(i int) uint8 { return uint8((i*255 + (n-1)/2) / (n - 1)) }
+	for b := 0; b < n; b++ {
+		for g := 0; g < n; g++ {
+			for r := 0; r < n; r++ {
+				img.SetRGBA(b*n+r, g, color.RGBA{c(r), c(g), c(b), 0xff})
+			}
+		}
+	}
+	return img
+}
+
+// CRT returns an effect simulating a CRT monitor: the image is curved by a
+// barrel distortion of the given curvature, 0 for none, and every other line
+// of pixels is darkened by scanlines, in the range [0, 1]. Both are set by the
+// uCurvature and uScanlines uniforms.
+//
+func CRT(curvature, scanlines float32) (Effect, error) {
+	e, err := newEffect(crtSrc)
+	if err != nil {
+		return nil, err
+	}
+	e[0].SetUniform("uCurvature", curvature)
+	e[0].SetUniform("uScanlines", scanlines)
+	return e, nil
+}
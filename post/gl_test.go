@@ -0,0 +1,21 @@
+// +build egl
+
+package post
+
+import (
+	"testing"
+
+	"github.com/db47h/grog/internal/egl"
+)
+
+// glContext makes a headless OpenGL context current on the calling goroutine,
+// creating it on first use, and returns a function that releases it. Tests
+// that need OpenGL only run with the egl build tag.
+//
+func glContext(tb testing.TB) (release func()) {
+	release, err := egl.Acquire()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return release
+}
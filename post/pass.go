@@ -0,0 +1,205 @@
+package post
+
+import (
+	"github.com/db47h/grog"
+	"github.com/db47h/grog/gl"
+	"golang.org/x/xerrors"
+)
+
+// A Pass is a full-screen shader pass.
+//
+// The program of a pass draws a quad covering the whole target with the
+// following vertex attribute, in the range [-1, 1]:
+//
+//	attribute vec2 aPos;
+//
+// It can use the following uniforms, set by the Chain:
+//
+//	uniform sampler2D uSource; // output of the previous pass
+//	uniform sampler2D uInput;  // input of the current effect
+//	uniform vec2 uTexelSize;   // size of a texel of uSource in UV units
+//
+// Since both textures are the size of the target, texture coordinates are
+// aPos * 0.5 + 0.5. Textures are in the OpenGL convention, with (0, 0) in the
+// bottom left corner.
+//
+// Colors are alpha premultiplied, and blending is disabled: the output of a
+// pass replaces the contents of its target.
+//
+type Pass struct {
+	program  gl.Program
+	pos      uint32 // aPos location
+	source   int32  // uSource location
+	input    int32  // uInput location
+	texel    int32  // uTexelSize location
+	uniforms []passUniform
+	textures []passTexture
+}
+
+type passUniform struct {
+	name string
+	loc  int32
+	v    []float32
+}
+
+type passTexture struct {
+	name string
+	loc  int32
+	t    *grog.Texture
+}
+
+// NewPass returns a new Pass for program p. It returns an error if p does not
+// have an aPos attribute.
+//
+func NewPass(p gl.Program) (*Pass, error) {
+	pos, err := p.AttribLocation("aPos")
+	if err != nil {
+		return nil, xerrors.Errorf("post-processing pass: %w", err)
+	}
+	return &Pass{
+		program: p,
+		pos:     pos,
+		source:  p.UniformLocation("uSource"),
+		input:   p.UniformLocation("uInput"),
+		texel:   p.UniformLocation("uTexelSize"),
+	}, nil
+}
+
+// NewShaderPass compiles a new Pass from the source of a fragment shader
+// function:
+//
+//	vec4 effect(vec2 uv)
+//
+// which returns the color of the output pixel at texture coordinates uv. The
+// function is written in GLSL ES 1.00 and must not include a #version
+// directive. It can declare additional uniforms, and use the uSource, uInput
+// and uTexelSize uniforms, which are declared by NewShaderPass. For example:
+//
+//	p, err := post.NewShaderPass(`
+//	uniform float uAmount;
+//
+//	vec4 effect(vec2 uv) {
+//	    vec4 c = texture2D(uSource, uv);
+//	    float l = dot(c.rgb, vec3(0.299, 0.587, 0.114));
+//	    return vec4(mix(c.rgb, vec3(l), uAmount), c.a);
+//	}`)
+//
+// The shader is adjusted to the current context, in particular texture2D is
+// replaced with texture for OpenGL core profile contexts.
+//
+func NewShaderPass(src string) (*Pass, error) {
+	vs, err := gl.NewShader(gl.GL_VERTEX_SHADER, vertexShader())
+	if err != nil {
+		return nil, xerrors.Errorf("post-processing pass vertex shader: %w", err)
+	}
+	defer vs.Delete()
+	fs, err := gl.NewShader(gl.GL_FRAGMENT_SHADER, fragmentShader(src))
+	if err != nil {
+		return nil, xerrors.Errorf("post-processing pass fragment shader: %w", err)
+	}
+	defer fs.Delete()
+	p, err := gl.NewProgram(vs, fs)
+	if err != nil {
+		return nil, xerrors.Errorf("post-processing pass: %w", err)
+	}
+	pass, err := NewPass(p)
+	if err != nil {
+		p.Delete()
+		return nil, err
+	}
+	return pass, nil
+}
+
+// Program returns the pass's shader program.
+//
+func (p *Pass) Program() gl.Program {
+	return p.program
+}
+
+// SetUniform sets the value of the named uniform. The value is kept by the
+// pass and set every time the pass is applied. Like for
+// grog.MaterialRenderer, v can be a float, vec2, vec3, vec4, mat3 or mat4.
+//
+func (p *Pass) SetUniform(name string, v ...float32) {
+	gl.CheckUniform(v)
+	for i := range p.uniforms {
+		if u := &p.uniforms[i]; u.name == name {
+			u.v = append(u.v[:0], v...)
+			return
+		}
+	}
+	p.uniforms = append(p.uniforms, passUniform{
+		name: name,
+		loc:  p.program.UniformLocation(name),
+		v:    append([]float32(nil), v...),
+	})
+}
+
+// SetTexture sets the texture used by the named sampler uniform. Unlike for
+// grog drawables, texture coordinates are in the OpenGL convention, with
+// (0, 0) at the start of the first row of pixels of the texture.
+//
+func (p *Pass) SetTexture(name string, t *grog.Texture) {
+	for i := range p.textures {
+		if pt := &p.textures[i]; pt.name == name {
+			pt.t = t
+			return
+		}
+	}
+	p.textures = append(p.textures, passTexture{name: name, loc: p.program.UniformLocation(name), t: t})
+}
+
+// Delete deletes the pass's shader program.
+//
+func (p *Pass) Delete() {
+	p.program.Delete()
+}
+
+// apply draws q with the program of the pass, with src and in bound to the
+// uSource and uInput samplers.
+//
+func (p *Pass) apply(q *quad, src, in *grog.RenderTarget) {
+	p.program.Use()
+	bindTexture(0, p.source, src.NativeID())
+	bindTexture(1, p.input, in.NativeID())
+	for i := range p.textures {
+		t := &p.textures[i]
+		bindTexture(2+i, t.loc, t.t.NativeID())
+	}
+	gl.ActiveTexture(gl.GL_TEXTURE0)
+	if p.texel >= 0 {
+		sz := src.Size()
+		gl.Uniform2f(p.texel, 1/float32(sz.X), 1/float32(sz.Y))
+	}
+	for i := range p.uniforms {
+		u := &p.uniforms[i]
+		gl.SetUniform(u.loc, u.v)
+	}
+	q.draw(p.pos)
+}
+
+// bindTexture binds texture id to texture unit and sets the sampler uniform at
+// location loc to use it. It does nothing if loc is negative.
+//
+func bindTexture(unit int, loc int32, id uint32) {
+	if loc < 0 {
+		return
+	}
+	gl.ActiveTexture(gl.GL_TEXTURE0 + uint32(unit))
+	gl.BindTexture(gl.GL_TEXTURE_2D, id)
+	gl.Uniform1i(loc, int32(unit))
+}
+
+// An Effect is a sequence of passes applied in order. The uInput sampler of
+// all the passes of an effect is the output of the previous effect, or the
+// scene for the first effect of a Chain.
+//
+type Effect []*Pass
+
+// Delete deletes the passes of the effect.
+//
+func (e Effect) Delete() {
+	for _, p := range e {
+		p.Delete()
+	}
+}
@@ -0,0 +1,347 @@
+// Package post implements post-processing effects for grog: a scene is
+// rendered into an offscreen grog.RenderTarget, then processed by an ordered
+// list of full-screen shader passes before being drawn into a View.
+//
+// Effects are scoped to a single View: views drawn directly, like a HUD, are
+// not affected. A typical frame looks like:
+//
+//	chain, err := post.NewChain(view.Size())
+//	bloom, err := post.Bloom(0.7, 1, 4)
+//	vignette, err := post.Vignette(0.5, 0.5)
+//	chain.Add(bloom, vignette)
+//
+//	// draw loop
+//	b.Begin()
+//	b.Camera(chain.Camera(view))
+//	b.Clear(color.Black)
+//	// draw scene
+//	// ...
+//	b.End()
+//	chain.Apply(view)
+//	b.Begin()
+//	b.Camera(hud)
+//	// draw HUD
+//	// ...
+//	b.End()
+//
+// The package ships with blur, bloom, vignette, color grading and CRT effects.
+// Custom passes can be created with NewPass or NewShaderPass.
+//
+package post
+
+import (
+	"image"
+
+	"github.com/db47h/grog"
+	"github.com/db47h/grog/gl"
+	"golang.org/x/xerrors"
+)
+
+// A Chain renders a scene into an offscreen target and applies a list of
+// effects to it.
+//
+// The Chain uses four render targets: one for the scene, with a stencil
+// buffer for clip masks, and three more between which passes ping-pong. The
+// scene target is never written to by passes, and the input of each effect is
+// kept until all its passes have been applied.
+//
+type Chain struct {
+	effects []Effect
+	targets [4]*grog.RenderTarget
+	scene   grog.View
+	copy    *Pass // draws the scene as-is when there are no passes
+	quad    quad
+}
+
+// NewChain returns a new Chain whose render targets have the given size, which
+// should be the size of the views it is used with.
+//
+func NewChain(size image.Point) (*Chain, error) {
+	c := new(Chain)
+	if err := c.Resize(size); err != nil {
+		return nil, err
+	}
+	cp, err := NewShaderPass("vec4 effect(vec2 uv) { return texture2D(uSource, uv); }\n")
+	if err != nil {
+		c.deleteTargets()
+		return nil, err
+	}
+	c.copy = cp
+	c.quad = newQuad()
+	return c, nil
+}
+
+// Resize resizes the render targets of the chain. It must be called whenever
+// the size of the view the chain is used with changes.
+//
+func (c *Chain) Resize(size image.Point) error {
+	if c.targets[0] != nil && c.targets[0].Size() == size {
+		return nil
+	}
+	c.deleteTargets()
+	for i := range c.targets {
+		rt, err := grog.NewRenderTarget(size.X, size.Y, i == 0,
+			grog.Filter(grog.Linear, grog.Linear), grog.Wrap(grog.ClampToEdge, grog.ClampToEdge))
+		if err != nil {
+			c.deleteTargets()
+			return xerrors.Errorf("post-processing chain: %w", err)
+		}
+		c.targets[i] = rt
+	}
+	c.scene.Fb = c.targets[0]
+	c.scene.Rect = image.Rectangle{Max: size}
+	return nil
+}
+
+func (c *Chain) deleteTargets() {
+	for i, rt := range c.targets {
+		if rt != nil {
+			rt.Delete()
+			c.targets[i] = nil
+		}
+	}
+}
+
+// Add appends effects to the chain.
+//
+func (c *Chain) Add(effects ...Effect) {
+	c.effects = append(c.effects, effects...)
+}
+
+// Effects returns the effects of the chain. The returned slice can be
+// modified to reorder or remove effects.
+//
+func (c *Chain) Effects() []Effect {
+	return c.effects
+}
+
+// SetEffects replaces the effects of the chain.
+//
+func (c *Chain) SetEffects(effects ...Effect) {
+	c.effects = effects
+}
+
+// Camera returns a camera rendering into the scene target of the chain, with
+// the same origin, scale and angle as v.
+//
+func (c *Chain) Camera(v *grog.View) *grog.View {
+	c.scene.Origin = v.Origin
+	c.scene.OrgPos = v.OrgPos
+	c.scene.Scale = v.Scale
+	c.scene.Angle = v.Angle
+	return &c.scene
+}
+
+// Scene returns the render target holding the scene. Apply does not modify
+// it: after Apply, it still holds the scene as drawn.
+//
+func (c *Chain) Scene() *grog.RenderTarget {
+	return c.targets[0]
+}
+
+// Apply applies all effects to the scene and draws the result into the
+// rectangle of view dst, in its parent FrameBuffer. If the parent FrameBuffer
+// is not a grog.RenderTarget, it is the framebuffer bound when calling Apply,
+// usually the screen.
+//
+// Apply changes the OpenGL state used by batches and must be called outside of
+// a batch Begin/End pair. The scene must have been flushed.
+//
+func (c *Chain) Apply(dst *grog.View) {
+	var st glState
+	st.save()
+	defer st.restore()
+	gl.Disable(gl.GL_BLEND)
+	gl.Disable(gl.GL_STENCIL_TEST)
+	gl.Disable(gl.GL_SCISSOR_TEST)
+	c.quad.bind()
+	defer c.quad.unbind()
+
+	if !c.passes(func(p *Pass, src, in, out int) {
+		if out < 0 {
+			c.present(p, src, in, dst, &st)
+			return
+		}
+		rt := c.targets[out]
+		sz := rt.Size()
+		gl.BindFramebuffer(gl.GL_FRAMEBUFFER, rt.FramebufferID())
+		gl.Viewport(0, 0, int32(sz.X), int32(sz.Y))
+		p.apply(&c.quad, c.targets[src], c.targets[in])
+	}) {
+		c.present(c.copy, 0, 0, dst, &st)
+	}
+}
+
+// passes calls f for each pass of the chain, in order, with the indices of
+// the targets holding its source, which is the output of the previous pass,
+// its input, which is the output of the previous effect, and of the target to
+// write its output to. The scene is target 0. out is -1 for the last pass,
+// whose output is drawn into the destination view. passes returns false if
+// there are no passes.
+//
+func (c *Chain) passes(f func(p *Pass, src, in, out int)) bool {
+	n := 0
+	for _, e := range c.effects {
+		n += len(e)
+	}
+	if n == 0 {
+		return false
+	}
+	in, src := 0, 0
+	for _, e := range c.effects {
+		for _, p := range e {
+			if n--; n == 0 {
+				f(p, src, in, -1)
+				return true
+			}
+			out := nextTarget(src, in)
+			f(p, src, in, out)
+			src = out
+		}
+		in = src
+	}
+	return true
+}
+
+// nextTarget returns the index of the target to write the output of a pass
+// to: the first target other than the scene, src and in.
+//
+func nextTarget(src, in int) int {
+	out := 1
+	for out == src || out == in {
+		out++
+	}
+	return out
+}
+
+// present applies pass p with the targets at index src and in, and draws its
+// output into view dst.
+//
+func (c *Chain) present(p *Pass, src, in int, dst *grog.View, st *glState) {
+	fbo := uint32(st.fbo)
+	if rt, ok := dst.Fb.(*grog.RenderTarget); ok {
+		fbo = rt.FramebufferID()
+	}
+	gl.BindFramebuffer(gl.GL_FRAMEBUFFER, fbo)
+	r := dst.GLRect()
+	gl.Viewport(int32(r.Min.X), int32(r.Min.Y), int32(r.Dx()), int32(r.Dy()))
+	gl.Scissor(int32(r.Min.X), int32(r.Min.Y), int32(r.Dx()), int32(r.Dy()))
+	gl.Enable(gl.GL_SCISSOR_TEST)
+	p.apply(&c.quad, c.targets[src], c.targets[in])
+}
+
+// Delete deletes the render targets of the chain, and the passes of all its
+// effects.
+//
+func (c *Chain) Delete() {
+	for _, e := range c.effects {
+		e.Delete()
+	}
+	c.effects = nil
+	c.copy.Delete()
+	c.quad.delete()
+	c.deleteTargets()
+}
+
+// glState is the OpenGL state changed by Apply.
+//
+type glState struct {
+	fbo      int32
+	viewport [4]int32
+	scissor  [4]int32
+	enabled  [3]bool // blend, stencil and scissor tests
+}
+
+var glStateCaps = [3]uint32{gl.GL_BLEND, gl.GL_STENCIL_TEST, gl.GL_SCISSOR_TEST}
+
+func (s *glState) save() {
+	gl.GetIntegerv(gl.GL_FRAMEBUFFER_BINDING, &s.fbo)
+	gl.GetIntegerv(gl.GL_VIEWPORT, &s.viewport[0])
+	gl.GetIntegerv(gl.GL_SCISSOR_BOX, &s.scissor[0])
+	for i, c := range glStateCaps {
+		s.enabled[i] = gl.IsEnabled(c) != gl.GL_FALSE
+	}
+}
+
+func (s *glState) restore() {
+	gl.BindFramebuffer(gl.GL_FRAMEBUFFER, uint32(s.fbo))
+	gl.Viewport(s.viewport[0], s.viewport[1], s.viewport[2], s.viewport[3])
+	gl.Scissor(s.scissor[0], s.scissor[1], s.scissor[2], s.scissor[3])
+	for i, c := range glStateCaps {
+		if s.enabled[i] {
+			gl.Enable(c)
+		} else {
+			gl.Disable(c)
+		}
+	}
+}
+
+// A quad is the vertex buffer of a full-screen quad, drawn as a triangle
+// strip.
+//
+type quad struct {
+	vao uint32 // 0 if vertex array objects are not available
+	vbo uint32
+	pos uint32 // enabled aPos attribute location
+}
+
+func newQuad() quad {
+	var q quad
+	v := [8]float32{-1, -1, 1, -1, -1, 1, 1, 1}
+	if gl.HasVertexArrays() {
+		gl.GenVertexArrays(1, &q.vao)
+		gl.BindVertexArray(q.vao)
+	}
+	gl.GenBuffers(1, &q.vbo)
+	gl.BindBuffer(gl.GL_ARRAY_BUFFER, q.vbo)
+	gl.BufferData(gl.GL_ARRAY_BUFFER, len(v)*4, gl.Ptr(&v[0]), gl.GL_STATIC_DRAW)
+	if q.vao != 0 {
+		gl.BindVertexArray(0)
+	}
+	q.pos = ^uint32(0)
+	return q
+}
+
+// bind binds the buffers of the quad.
+//
+func (q *quad) bind() {
+	if q.vao != 0 {
+		gl.BindVertexArray(q.vao)
+	}
+	gl.BindBuffer(gl.GL_ARRAY_BUFFER, q.vbo)
+}
+
+// unbind disables the vertex attribute enabled by draw when vertex array
+// objects are not available, so that it does not interfere with batches.
+//
+func (q *quad) unbind() {
+	if q.vao != 0 {
+		gl.BindVertexArray(0)
+		return
+	}
+	if q.pos != ^uint32(0) {
+		gl.DisableVertexAttribArray(q.pos)
+		q.pos = ^uint32(0)
+	}
+}
+
+// draw draws the quad with its vertices bound to attribute location pos.
+//
+func (q *quad) draw(pos uint32) {
+	if pos != q.pos {
+		if q.pos != ^uint32(0) && q.vao == 0 {
+			gl.DisableVertexAttribArray(q.pos)
+		}
+		gl.EnableVertexAttribArray(pos)
+		gl.VertexAttribOffset(pos, 2, gl.GL_FLOAT, gl.GL_FALSE, 0, 0)
+		q.pos = pos
+	}
+	gl.DrawArrays(gl.GL_TRIANGLE_STRIP, 0, 4)
+}
+
+func (q *quad) delete() {
+	gl.DeleteBuffers(1, &q.vbo)
+	if q.vao != 0 {
+		gl.DeleteVertexArrays(1, &q.vao)
+	}
+}
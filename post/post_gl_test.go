@@ -0,0 +1,121 @@
+// +build egl
+
+package post
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/db47h/grog"
+	"github.com/db47h/grog/gl"
+	"github.com/db47h/grog/internal/egl"
+)
+
+// shaderPass returns a new pass for the effect function src.
+//
+func shaderPass(t *testing.T, src string) *Pass {
+	t.Helper()
+	p, err := NewShaderPass(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestChain_Apply(t *testing.T) {
+	defer glContext(t)()
+	var (
+		red     = color.RGBA{255, 0, 0, 255}
+		green   = color.RGBA{0, 255, 0, 255}
+		blue    = color.RGBA{0, 0, 255, 255}
+		magenta = color.RGBA{255, 0, 255, 255}
+		screen  = grog.NewScreen(image.Pt(egl.Width, egl.Height))
+		view    = &grog.View{Fb: screen, Rect: image.Rect(0, 0, 32, 32), Scale: 1}
+		hud     = &grog.View{Fb: screen, Rect: image.Rect(64, 0, 96, 32), Scale: 1}
+	)
+	chain, err := NewChain(view.Rect.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Delete()
+	// red -> green -> blue, then blue -> red -> red, plus the input of the
+	// second effect, blue: the result is magenta only if the input of the
+	// second effect survives the ping-pong of its passes.
+	chain.Add(
+		Effect{
+			shaderPass(t, "vec4 effect(vec2 uv) { return texture2D(uSource, uv).grba; }\n"),
+			shaderPass(t, "vec4 effect(vec2 uv) { return texture2D(uSource, uv).rbga; }\n"),
+		},
+		Effect{
+			shaderPass(t, "vec4 effect(vec2 uv) { return texture2D(uSource, uv).bgra; }\n"),
+			shaderPass(t, "vec4 effect(vec2 uv) { return texture2D(uSource, uv); }\n"),
+			shaderPass(t, "vec4 effect(vec2 uv) { return texture2D(uSource, uv) + texture2D(uInput, uv); }\n"),
+		},
+	)
+
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	draw.Draw(img, img.Rect, image.NewUniform(blue), image.ZP, draw.Src)
+	sprite := grog.TextureFromImage(img, grog.Filter(grog.Nearest, grog.Nearest))
+	defer sprite.Delete()
+
+	b, err := grog.NewBatch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	b.Begin()
+	b.Camera(screen.View())
+	b.Clear(color.Transparent)
+	b.Camera(chain.Camera(view))
+	b.Clear(red)
+	b.End()
+
+	// GL state that Apply changes and must restore. Batches enable blending
+	// and the scissor test, and expect the viewport of the bound frame buffer.
+	gl.Viewport(0, 0, egl.Width, egl.Height)
+	gl.Scissor(5, 6, 7, 8)
+	gl.Enable(gl.GL_STENCIL_TEST)
+	chain.Apply(view)
+	var vp, sc [4]int32
+	gl.GetIntegerv(gl.GL_VIEWPORT, &vp[0])
+	gl.GetIntegerv(gl.GL_SCISSOR_BOX, &sc[0])
+	if vp != [4]int32{0, 0, egl.Width, egl.Height} || sc != [4]int32{5, 6, 7, 8} {
+		t.Errorf("viewport %v, scissor box %v not restored", vp, sc)
+	}
+	for _, c := range glStateCaps {
+		if gl.IsEnabled(c) == gl.GL_FALSE {
+			t.Errorf("capability 0x%x not enabled", c)
+		}
+	}
+	gl.Disable(gl.GL_STENCIL_TEST)
+
+	// HUD drawn directly, with its origin in its top left corner
+	b.Begin()
+	b.Camera(hud)
+	b.Clear(green)
+	b.Draw(sprite, grog.Pt(8, 8), grog.Pt(1, 1), 0, nil)
+	b.End()
+
+	img = screen.Capture(image.Rect(0, 0, 128, 32))
+	for _, c := range []struct {
+		name string
+		p    image.Point
+		want color.RGBA
+	}{
+		{"effected view", image.Pt(0, 0), magenta},
+		{"effected view", image.Pt(31, 31), magenta},
+		{"HUD", image.Pt(64, 0), green},
+		{"HUD sprite", image.Pt(73, 9), blue},
+		{"outside of the views", image.Pt(40, 8), color.RGBA{}},
+		{"outside of the views", image.Pt(100, 8), color.RGBA{}},
+	} {
+		if got := img.RGBAAt(c.p.X, c.p.Y); got != c.want {
+			t.Errorf("%s: pixel %v = %v, want %v", c.name, c.p, got, c.want)
+		}
+	}
+	if got := chain.Scene().Capture(image.Rect(0, 0, 1, 1)).RGBAAt(0, 0); got != red {
+		t.Errorf("scene pixel = %v, want %v", got, red)
+	}
+}
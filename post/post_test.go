@@ -0,0 +1,98 @@
+package post
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestNextTarget(t *testing.T) {
+	for _, tc := range []struct{ src, in, want int }{
+		{0, 0, 1},
+		{1, 0, 2},
+		{2, 0, 1},
+		{1, 1, 2},
+		{2, 2, 1},
+		{1, 2, 3},
+		{2, 1, 3},
+		{3, 1, 2},
+		{2, 3, 1},
+	} {
+		if got := nextTarget(tc.src, tc.in); got != tc.want {
+			t.Errorf("nextTarget(%d, %d) = %d, want %d", tc.src, tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestChain_passes(t *testing.T) {
+	for _, layout := range [][]int{
+		{},
+		{0},
+		{1},
+		{3},
+		{1, 1},
+		{2, 2},
+		{1, 1, 1, 1},
+		{3, 1, 2},
+		{1, 3, 0, 1, 2},
+	} {
+		var (
+			c     Chain
+			pos   = make(map[*Pass][2]int) // effect and pass index
+			total int
+		)
+		for i, n := range layout {
+			var e Effect
+			for j := 0; j < n; j++ {
+				p := new(Pass)
+				pos[p] = [2]int{i, j}
+				e = append(e, p)
+			}
+			c.effects = append(c.effects, e)
+			total += n
+		}
+
+		// simulate the contents of the targets
+		var (
+			name     = fmt.Sprint(layout)
+			contents = [len(c.targets)]string{"scene"}
+			input    = "scene" // output of the previous effect
+			last     = "scene" // output of the previous pass
+			effect   = -1
+			calls    int
+		)
+		ok := c.passes(func(p *Pass, src, in, out int) {
+			calls++
+			ij := pos[p]
+			if ij[0] != effect {
+				effect, input = ij[0], last
+			}
+			label := fmt.Sprintf("pass %d.%d", ij[0], ij[1])
+			if contents[src] != last {
+				t.Errorf("%s: %s: source target %d holds %q, want %q", name, label, src, contents[src], last)
+			}
+			if contents[in] != input {
+				t.Errorf("%s: %s: input target %d holds %q, want %q", name, label, in, contents[in], input)
+			}
+			if out == -1 {
+				if calls != total {
+					t.Errorf("%s: %s: presented after %d of %d passes", name, label, calls, total)
+				}
+				return
+			}
+			if out <= 0 || out >= len(c.targets) || out == src || out == in {
+				t.Fatalf("%s: %s: invalid output target %d (source %d, input %d)", name, label, out, src, in)
+			}
+			contents[out] = label
+			last = label
+		})
+		if ok != (total > 0) {
+			t.Errorf("%s: passes returned %v", name, ok)
+		}
+		if calls != total {
+			t.Errorf("%s: %d passes applied, want %d", name, calls, total)
+		}
+		if contents[0] != "scene" {
+			t.Errorf("%s: scene overwritten by %s", name, contents[0])
+		}
+	}
+}
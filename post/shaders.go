@@ -0,0 +1,62 @@
+package post
+
+import (
+	"strings"
+
+	"github.com/db47h/grog/gl"
+)
+
+// version returns the #version directive of pass shaders for the current
+// context, and whether it is a core profile context, where inputs and outputs
+// are declared with in and out.
+//
+func version() (v string, core bool) {
+	if gl.RuntimeCoreProfile() {
+		return "#version 150 core", true
+	}
+	return compatVersion, false
+}
+
+// vertexShader returns the source of the vertex shader of passes.
+//
+func vertexShader() []byte {
+	v, core := version()
+	in, out := "attribute", "varying"
+	if core {
+		in, out = "in", "out"
+	}
+	return []byte(v + "\n" + in + " vec2 aPos;\n" + out + ` vec2 vTexCoords;
+
+void main()
+{
+    gl_Position = vec4(aPos, 0.0, 1.0);
+    vTexCoords = aPos * 0.5 + 0.5;
+}
+`)
+}
+
+// fragmentShader returns the source of a fragment shader whose output is set
+// by the effect function defined in src.
+//
+func fragmentShader(src string) []byte {
+	var (
+		b         strings.Builder
+		v, core   = version()
+		in, color = "varying", "gl_FragColor"
+	)
+	b.WriteString(v + "\nprecision mediump float;\n\n")
+	if core {
+		b.WriteString("#define texture2D texture\nout vec4 fragColor;\n")
+		in, color = "in", "fragColor"
+	}
+	b.WriteString(in + ` vec2 vTexCoords;
+
+uniform sampler2D uSource;
+uniform sampler2D uInput;
+uniform vec2 uTexelSize;
+
+`)
+	b.WriteString(src)
+	b.WriteString("\nvoid main()\n{\n    " + color + " = effect(vTexCoords);\n}\n")
+	return []byte(b.String())
+}
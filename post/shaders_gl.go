@@ -0,0 +1,8 @@
+// +build !gles2
+
+package post
+
+// compatVersion is the #version directive of shaders for OpenGL contexts that
+// are not core profile contexts.
+//
+const compatVersion = "#version 130"
@@ -0,0 +1,7 @@
+// +build gles2
+
+package post
+
+// compatVersion is the #version directive of shaders for OpenGLES contexts.
+//
+const compatVersion = "#version 100"
@@ -0,0 +1,132 @@
+package grog
+
+import (
+	"image"
+
+	"github.com/db47h/grog/gl"
+)
+
+// Capture returns a copy of the pixels of the screen within r, in screen pixel
+// coordinates. The bounds of the returned image have the same size as r
+// clipped to the screen, with their top left corner at (0, 0). Like any
+// image.RGBA, it holds alpha premultiplied colors; draw it into an image.NRGBA
+// to get non-premultiplied colors.
+//
+// Capture reads from the frame buffer currently bound, which is the screen
+// outside of a batch Begin/End pair. Draw calls must have been flushed, and
+// Capture must be called before swapping buffers:
+//
+//	b.End()
+//	if screenshot {
+//		img := screen.Capture(image.Rectangle{Max: screen.Size()})
+//		// encode img in a goroutine
+//		// ...
+//	}
+//	window.SwapBuffers()
+//
+func (s *Screen) Capture(r image.Rectangle) *image.RGBA {
+	return readPixels(r.Intersect(s.v.Rect), s.Size().Y, true)
+}
+
+// Capture returns a copy of the pixels of the RenderTarget within r. See
+// Screen.Capture. Unlike Screen.Capture, it can be called at any time, as long
+// as draw calls rendering into the target have been flushed.
+//
+func (rt *RenderTarget) Capture(r image.Rectangle) *image.RGBA {
+	var prev int32
+	gl.GetIntegerv(gl.GL_FRAMEBUFFER_BINDING, &prev)
+	gl.BindFramebuffer(gl.GL_FRAMEBUFFER, rt.fbo)
+	img := readPixels(r.Intersect(image.Rect(0, 0, rt.width, rt.height)), rt.height, true)
+	gl.BindFramebuffer(gl.GL_FRAMEBUFFER, uint32(prev))
+	return img
+}
+
+// Image downloads the texture into a new image. Like any image.RGBA, the image
+// holds alpha premultiplied colors; draw it into an image.NRGBA to get
+// non-premultiplied colors.
+//
+func (t *Texture) Image() *image.RGBA {
+	return t.image(image.Rect(0, 0, t.width, t.height))
+}
+
+// Image downloads the region of the texture into a new image. The bounds of
+// the image have the same size as the region, with their top left corner at
+// (0, 0). See Texture.Image.
+//
+func (r *Region) Image() *image.RGBA {
+	return r.Texture.image(r.bounds)
+}
+
+// readPixels reads the pixels within r of the currently bound frame buffer,
+// with the top left corner of r at (0, 0) in the returned image. If flipY is
+// true, r has y = 0 at the top of the frame buffer, which is height pixels
+// high, otherwise at the bottom. The frame buffer is expected to hold alpha
+// premultiplied colors; they are returned as is, clamped by
+// clampPremultiplied.
+//
+func readPixels(r image.Rectangle, height int, flipY bool) *image.RGBA {
+	img := image.NewRGBA(image.Rectangle{Max: r.Size()})
+	if r.Empty() {
+		return img
+	}
+	y := r.Min.Y
+	if flipY {
+		y = height - r.Max.Y
+	}
+	gl.ReadPixels(int32(r.Min.X), int32(y), int32(r.Dx()), int32(r.Dy()), gl.GL_RGBA, gl.GL_UNSIGNED_BYTE, gl.Ptr(&img.Pix[0]))
+	if flipY {
+		flipRows(img)
+	}
+	clampPremultiplied(img)
+	return img
+}
+
+// readFramebuffer downloads the pixels within r of the texture by attaching it
+// to a temporary framebuffer object and reading them with glReadPixels.
+//
+func (t *Texture) readFramebuffer(r image.Rectangle) *image.RGBA {
+	var (
+		prev int32
+		fbo  uint32
+	)
+	gl.GetIntegerv(gl.GL_FRAMEBUFFER_BINDING, &prev)
+	gl.GenFramebuffers(1, &fbo)
+	gl.BindFramebuffer(gl.GL_FRAMEBUFFER, fbo)
+	gl.FramebufferTexture2D(gl.GL_FRAMEBUFFER, gl.GL_COLOR_ATTACHMENT0, gl.GL_TEXTURE_2D, t.glID, 0)
+	img := readPixels(r.Intersect(image.Rect(0, 0, t.width, t.height)), t.height, t.flipY)
+	gl.BindFramebuffer(gl.GL_FRAMEBUFFER, uint32(prev))
+	gl.DeleteFramebuffers(1, &fbo)
+	return img
+}
+
+// flipRows flips img vertically.
+//
+func flipRows(img *image.RGBA) {
+	for i, j := 0, (img.Rect.Dy()-1)*img.Stride; i < j; i, j = i+img.Stride, j-img.Stride {
+		r0, r1 := img.Pix[i:i+img.Stride], img.Pix[j:j+img.Stride]
+		for k := range r0 {
+			r0[k], r1[k] = r1[k], r0[k]
+		}
+	}
+}
+
+// clampPremultiplied clamps color components to alpha. Like image.RGBA, frame
+// buffers and textures hold alpha premultiplied colors, but some blend modes,
+// like BlendAdditive, can produce colors brighter than their alpha value, which
+// are not valid alpha premultiplied colors.
+//
+func clampPremultiplied(img *image.RGBA) {
+	p := img.Pix
+	for i := 0; i+3 < len(p); i += 4 {
+		a := p[i+3]
+		if p[i] > a {
+			p[i] = a
+		}
+		if p[i+1] > a {
+			p[i+1] = a
+		}
+		if p[i+2] > a {
+			p[i+2] = a
+		}
+	}
+}
@@ -0,0 +1,39 @@
+// +build !gles2
+
+package grog
+
+import (
+	"image"
+	"image/draw"
+
+	"github.com/db47h/grog/gl"
+)
+
+// image downloads the pixels within r of the texture. Sub-rectangles are read
+// through a framebuffer object when available, so that only the pixels within
+// r are transferred; otherwise, and for the whole texture, glGetTexImage is
+// used.
+//
+func (t *Texture) image(r image.Rectangle) *image.RGBA {
+	full := image.Rect(0, 0, t.width, t.height)
+	r = r.Intersect(full)
+	if r.Empty() {
+		return image.NewRGBA(image.Rectangle{Max: r.Size()})
+	}
+	if r != full && gl.HasFramebuffers() {
+		return t.readFramebuffer(r)
+	}
+	img := image.NewRGBA(full)
+	gl.BindTexture(gl.GL_TEXTURE_2D, t.glID)
+	gl.GetTexImage(gl.GL_TEXTURE_2D, 0, gl.GL_RGBA, gl.GL_UNSIGNED_BYTE, gl.Ptr(&img.Pix[0]))
+	if t.flipY {
+		flipRows(img)
+	}
+	if r != full {
+		dst := image.NewRGBA(image.Rectangle{Max: r.Size()})
+		draw.Draw(dst, dst.Rect, img, r.Min, draw.Src)
+		img = dst
+	}
+	clampPremultiplied(img)
+	return img
+}
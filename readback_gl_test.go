@@ -0,0 +1,71 @@
+// +build egl
+
+package grog
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// checkImage compares img to the pixels of want within r.
+//
+func checkImage(t *testing.T, name string, img *image.RGBA, want *image.RGBA, r image.Rectangle) {
+	t.Helper()
+	if img.Rect != (image.Rectangle{Max: r.Size()}) {
+		t.Fatalf("%s: bounds = %v, want %v", name, img.Rect, image.Rectangle{Max: r.Size()})
+	}
+	for y := 0; y < r.Dy(); y++ {
+		for x := 0; x < r.Dx(); x++ {
+			if c, w := img.RGBAAt(x, y), want.RGBAAt(r.Min.X+x, r.Min.Y+y); c != w {
+				t.Fatalf("%s: pixel (%d, %d) = %v, want %v", name, x, y, c, w)
+			}
+		}
+	}
+}
+
+func TestTexture_Image(t *testing.T) {
+	defer glContext(t)()
+	src := image.NewRGBA(image.Rect(0, 0, 8, 6))
+	for y := 0; y < 6; y++ {
+		for x := 0; x < 8; x++ {
+			src.SetRGBA(x, y, color.RGBA{uint8(x * 30), uint8(y * 40), 100, 255})
+		}
+	}
+	src.SetRGBA(1, 1, color.RGBA{10, 20, 30, 40})
+	tex := TextureFromImage(src, Filter(Nearest, Nearest))
+	defer tex.Delete()
+
+	checkImage(t, "texture", tex.Image(), src, src.Rect)
+	for _, r := range []image.Rectangle{
+		image.Rect(1, 1, 4, 3),
+		image.Rect(0, 5, 8, 6),
+		image.Rect(6, 0, 10, 4), // clipped to the texture
+	} {
+		checkImage(t, "region "+r.String(), tex.Region(r, image.ZP).Image(), src, r.Intersect(src.Rect))
+	}
+	if img := tex.Region(image.Rect(10, 10, 12, 12), image.ZP).Image(); !img.Rect.Empty() {
+		t.Errorf("region outside of the texture: bounds = %v", img.Rect)
+	}
+
+	// render targets are stored bottom up
+	rt, err := NewRenderTarget(8, 6, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rt.Delete()
+	b, err := NewBatch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	b.Begin()
+	b.Camera(rt.View())
+	b.SetBlendMode(BlendReplace)
+	b.Draw(tex, Pt(0, 0), Pt(1, 1), 0, nil)
+	b.End()
+	checkImage(t, "render target", rt.Image(), src, src.Rect)
+	r := image.Rect(2, 1, 5, 4)
+	checkImage(t, "render target region", rt.Region(r, image.ZP).Image(), src, r)
+	checkImage(t, "capture", rt.Capture(r), src, r)
+}
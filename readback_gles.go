@@ -0,0 +1,14 @@
+// +build gles2
+
+package grog
+
+import (
+	"image"
+)
+
+// image downloads the pixels within r of the texture. OpenGLES has no
+// glGetTexImage: the texture is read through a framebuffer object.
+//
+func (t *Texture) image(r image.Rectangle) *image.RGBA {
+	return t.readFramebuffer(r)
+}
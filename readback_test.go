@@ -0,0 +1,47 @@
+package grog
+
+import (
+	"bytes"
+	"image"
+	"testing"
+)
+
+func TestFlipRows(t *testing.T) {
+	for h := 0; h <= 4; h++ {
+		img := image.NewRGBA(image.Rect(0, 0, 2, h))
+		for i := range img.Pix {
+			img.Pix[i] = byte(i)
+		}
+		want := make([]byte, len(img.Pix))
+		for y := 0; y < h; y++ {
+			copy(want[y*img.Stride:], img.Pix[(h-1-y)*img.Stride:(h-y)*img.Stride])
+		}
+		flipRows(img)
+		if !bytes.Equal(img.Pix, want) {
+			t.Errorf("height %d: got %v, want %v", h, img.Pix, want)
+		}
+	}
+}
+
+func TestClampPremultiplied(t *testing.T) {
+	img := &image.RGBA{
+		Pix: []byte{
+			10, 20, 30, 255, // valid
+			10, 20, 30, 20, // too bright
+			0, 200, 0, 0, // transparent
+			5, 5, 5, 5, // valid
+		},
+		Stride: 16,
+		Rect:   image.Rect(0, 0, 4, 1),
+	}
+	want := []byte{
+		10, 20, 30, 255,
+		10, 20, 20, 20,
+		0, 0, 0, 0,
+		5, 5, 5, 5,
+	}
+	clampPremultiplied(img)
+	if !bytes.Equal(img.Pix, want) {
+		t.Errorf("got %v, want %v", img.Pix, want)
+	}
+}
@@ -0,0 +1,157 @@
+package grog
+
+import (
+	"image"
+
+	"github.com/db47h/grog/gl"
+	"golang.org/x/xerrors"
+)
+
+// A RenderTarget is an offscreen FrameBuffer backed by an OpenGL framebuffer
+// object, which renders into a texture.
+//
+// Batches render into a RenderTarget when their camera is a View whose parent
+// FrameBuffer is the RenderTarget, or a CameraState recorded from such a View
+// by a DisplayList or command buffer. They render to the screen again when
+// the camera is set to any other camera, or when calling End:
+//
+//	rt, err := grog.NewRenderTarget(256, 256, false, grog.Filter(grog.Linear, grog.Linear))
+//	// ...
+//	b.Begin()
+//	b.Camera(rt.View())
+//	b.Clear(color.Transparent)
+//	// draw minimap
+//	// ...
+//	b.Camera(screen.View())
+//	b.Draw(rt, grog.Pt(10, 10), grog.Pt(1, 1), 0, nil)
+//	b.End()
+//
+// A RenderTarget is a Drawable for its whole texture. Although the rows of
+// framebuffer textures are stored bottom up, the RenderTarget and the regions
+// returned by its Region method are drawn upright, and region bounds have y = 0
+// at the top, like views. A RenderTarget must not be drawn while it is being
+// rendered into.
+//
+type RenderTarget struct {
+	*Texture
+	fbo uint32
+	rb  uint32 // depth and stencil renderbuffer, 0 if none
+	v   View
+}
+
+// NewRenderTarget returns a new RenderTarget with a texture of the given
+// width and height. If depthStencil is true, the RenderTarget has a depth and
+// stencil buffer, which clip masks require. Texture parameters apply to the
+// texture of the target.
+//
+// Framebuffer objects require OpenGL 3.0 or OpenGLES 2.0, and extension
+// functions loaded with gl.InitExtC or gl.InitExtGo. On OpenGLES 2.0, depth
+// and stencil buffers require the OES_packed_depth_stencil extension.
+//
+func NewRenderTarget(width, height int, depthStencil bool, params ...TextureParameter) (*RenderTarget, error) {
+	if !gl.HasFramebuffers() {
+		return nil, xerrors.New("framebuffer objects not available: extension functions not loaded")
+	}
+	var prev int32
+	gl.GetIntegerv(gl.GL_FRAMEBUFFER_BINDING, &prev)
+	defer gl.BindFramebuffer(gl.GL_FRAMEBUFFER, uint32(prev))
+
+	rt := &RenderTarget{Texture: NewTexture(width, height, params...)}
+	rt.flipY = true
+	rt.v = View{Fb: rt, Rect: image.Rect(0, 0, width, height), Scale: 1}
+	gl.GenFramebuffers(1, &rt.fbo)
+	gl.BindFramebuffer(gl.GL_FRAMEBUFFER, rt.fbo)
+	gl.FramebufferTexture2D(gl.GL_FRAMEBUFFER, gl.GL_COLOR_ATTACHMENT0, gl.GL_TEXTURE_2D, rt.glID, 0)
+	if depthStencil {
+		gl.GenRenderbuffers(1, &rt.rb)
+		gl.BindRenderbuffer(gl.GL_RENDERBUFFER, rt.rb)
+		gl.RenderbufferStorage(gl.GL_RENDERBUFFER, gl.GL_DEPTH24_STENCIL8, int32(width), int32(height))
+		gl.BindRenderbuffer(gl.GL_RENDERBUFFER, 0)
+		gl.FramebufferRenderbuffer(gl.GL_FRAMEBUFFER, gl.GL_DEPTH_ATTACHMENT, gl.GL_RENDERBUFFER, rt.rb)
+		gl.FramebufferRenderbuffer(gl.GL_FRAMEBUFFER, gl.GL_STENCIL_ATTACHMENT, gl.GL_RENDERBUFFER, rt.rb)
+	}
+	if st := gl.CheckFramebufferStatus(gl.GL_FRAMEBUFFER); st != gl.GL_FRAMEBUFFER_COMPLETE {
+		rt.Delete()
+		return nil, xerrors.Errorf("incomplete framebuffer: status 0x%x", st)
+	}
+	return rt, nil
+}
+
+// Size returns the size of the RenderTarget.
+//
+func (rt *RenderTarget) Size() image.Point {
+	return image.Pt(rt.width, rt.height)
+}
+
+// View returns the view covering the whole RenderTarget. Client code is free
+// to adjust the view Origin, Angle and Scale.
+//
+func (rt *RenderTarget) View() *View {
+	return &rt.v
+}
+
+// FramebufferID returns the OpenGL name of the framebuffer object.
+//
+func (rt *RenderTarget) FramebufferID() uint32 {
+	return rt.fbo
+}
+
+// Delete deletes the RenderTarget and its texture.
+//
+func (rt *RenderTarget) Delete() {
+	gl.DeleteFramebuffers(1, &rt.fbo)
+	if rt.rb != 0 {
+		gl.DeleteRenderbuffers(1, &rt.rb)
+	}
+	rt.Texture.Delete()
+}
+
+// cameraFb returns the frame buffer of a camera: the parent FrameBuffer of a
+// *View, the Fb field of a *CameraState, nil for other cameras.
+//
+func cameraFb(c Camera) FrameBuffer {
+	switch c := c.(type) {
+	case *View:
+		return c.Fb
+	case *CameraState:
+		return c.Fb
+	}
+	return nil
+}
+
+// cameraTarget returns the RenderTarget of a camera: its frame buffer if it is
+// a RenderTarget, nil otherwise.
+//
+func cameraTarget(c Camera) *RenderTarget {
+	rt, _ := cameraFb(c).(*RenderTarget)
+	return rt
+}
+
+// A targetBinding keeps track of the RenderTarget bound by a batch.
+//
+type targetBinding struct {
+	rt       *RenderTarget // nil if none
+	fbo      int32         // framebuffer bound before rt
+	viewport [4]int32      // viewport before rt
+}
+
+// bind binds rt and sets the viewport to its size. If rt is nil, the
+// framebuffer and viewport in use before binding a RenderTarget are restored.
+//
+func (t *targetBinding) bind(rt *RenderTarget) {
+	if rt == t.rt {
+		return
+	}
+	if t.rt == nil {
+		gl.GetIntegerv(gl.GL_FRAMEBUFFER_BINDING, &t.fbo)
+		gl.GetIntegerv(gl.GL_VIEWPORT, &t.viewport[0])
+	}
+	t.rt = rt
+	if rt == nil {
+		gl.BindFramebuffer(gl.GL_FRAMEBUFFER, uint32(t.fbo))
+		gl.Viewport(t.viewport[0], t.viewport[1], t.viewport[2], t.viewport[3])
+		return
+	}
+	gl.BindFramebuffer(gl.GL_FRAMEBUFFER, rt.fbo)
+	gl.Viewport(0, 0, int32(rt.width), int32(rt.height))
+}
@@ -0,0 +1,92 @@
+// +build egl
+
+package grog
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// solidTexture returns a w×h texture of color c.
+//
+func solidTexture(w, h int, c color.RGBA) *Texture {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = c.R, c.G, c.B, c.A
+	}
+	return TextureFromImage(img, Filter(Nearest, Nearest))
+}
+
+func TestRenderTarget_replay(t *testing.T) {
+	defer glContext(t)()
+	var (
+		red    = color.RGBA{255, 0, 0, 255}
+		green  = color.RGBA{0, 255, 0, 255}
+		blue   = color.RGBA{0, 0, 255, 255}
+		screen = NewScreen(image.Pt(glWidth, glHeight))
+		tex    = solidTexture(4, 4, red)
+	)
+	defer tex.Delete()
+	rt, err := NewRenderTarget(16, 16, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rt.Delete()
+
+	// scene recorded into a list
+	var l DisplayList
+	l.Camera(rt.View())
+	l.Clear(blue)
+	l.Draw(tex, Pt(2, 2), Pt(1, 1), 0, nil)
+
+	check := func(name string) {
+		t.Helper()
+		img := rt.Capture(image.Rect(0, 0, 16, 16))
+		if c := img.RGBAAt(3, 3); c != red {
+			t.Errorf("%s: sprite pixel = %v, want %v", name, c, red)
+		}
+		if c := img.RGBAAt(10, 10); c != blue {
+			t.Errorf("%s: background pixel = %v, want %v", name, c, blue)
+		}
+		if c := screen.Capture(image.Rect(0, 0, 1, 1)).RGBAAt(0, 0); c != green {
+			t.Errorf("%s: screen pixel = %v, want %v", name, c, green)
+		}
+	}
+
+	for _, bt := range []struct {
+		name string
+		opts []BatchOption
+	}{
+		{"sync", nil},
+		{"concurrent", []BatchOption{Concurrent(true)}},
+		{"instanced", []BatchOption{Instanced(true)}},
+	} {
+		b, err := NewBatch(bt.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		clear := func() {
+			b.Camera(screen.View())
+			b.Clear(green)
+			b.Camera(rt.View())
+			b.Clear(color.Transparent)
+			b.Camera(screen.View())
+		}
+
+		b.Begin()
+		clear()
+		l.Replay(b)
+		b.End()
+		check(bt.name + "/list")
+
+		if p, ok := b.(ParallelRenderer); ok {
+			b.Begin()
+			clear()
+			l.Replay(p.CommandBuffer(0))
+			b.End()
+			check(bt.name + "/command buffer")
+		}
+		b.Close()
+	}
+}
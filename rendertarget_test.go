@@ -0,0 +1,56 @@
+package grog
+
+import (
+	"image"
+	"testing"
+)
+
+func TestCameraTarget(t *testing.T) {
+	rt := &RenderTarget{Texture: &Texture{width: 64, height: 32}}
+	rt.v = View{Fb: rt, Rect: image.Rect(0, 0, 64, 32), Scale: 1}
+	screen := NewScreen(image.Pt(320, 200))
+	v := &View{Fb: rt, Rect: image.Rect(8, 4, 40, 20), Scale: 2, Origin: Pt(5, 3)}
+
+	var l DisplayList
+	l.Camera(rt.View())
+	l.Camera(v)
+	l.Camera(screen.View())
+	l.Camera(&CameraState{})
+	// replaying into a list records the same frame buffer
+	var l2 DisplayList
+	l.Replay(&l2)
+
+	for _, list := range []*DisplayList{&l, &l2} {
+		cmds := list.Commands()
+		for i, want := range []*RenderTarget{rt, rt, nil, nil} {
+			if got := cameraTarget(cmds[i].Camera); got != want {
+				t.Errorf("command %d: cameraTarget = %p, want %p", i, got, want)
+			}
+		}
+		if fb := cmds[2].Camera.Fb; fb != FrameBuffer(screen) {
+			t.Errorf("screen camera: Fb = %v", fb)
+		}
+	}
+	if cameraTarget(rt.View()) != rt || cameraTarget(screen.View()) != nil {
+		t.Error("wrong target for views")
+	}
+
+	// recorded cameras cull and snap like the view they were recorded from
+	var kv, ks culler
+	kv.setCamera(v)
+	ks.setCamera(l.Commands()[1].Camera)
+	if kv != ks {
+		t.Errorf("culling region: got %+v, want %+v", ks, kv)
+	}
+	sv := snapper{enabled: true}
+	sv.setCamera(v)
+	ss := snapper{enabled: true}
+	ss.setCamera(l.Commands()[1].Camera)
+	if !sv.valid {
+		t.Fatal("snapping disabled for the view")
+	}
+	sv.cam, ss.cam = nil, nil
+	if sv != ss {
+		t.Errorf("snapping: got %+v, want %+v", ss, sv)
+	}
+}
@@ -0,0 +1,145 @@
+package grog
+
+import "github.com/db47h/grog/gl"
+
+// A glslDialect is a variant of the GLSL language used for the default shaders.
+//
+type glslDialect int
+
+const (
+	glslCompat glslDialect = iota // GLSL 1.30 or GLSL ES 1.00: attribute/varying and gl_FragColor
+	glsl150                       // GLSL 1.50 core
+	glsl330                       // GLSL 3.30 core
+)
+
+// currentDialect returns the dialect of the default shaders for the current
+// context. Core profile contexts require GLSL 1.50 or higher.
+//
+func currentDialect() glslDialect {
+	if !gl.RuntimeCoreProfile() {
+		return glslCompat
+	}
+	if gl.RuntimeVersion().GE(gl.OpenGL, 3, 3) {
+		return glsl330
+	}
+	return glsl150
+}
+
+// version returns the #version directive of the dialect.
+//
+func (d glslDialect) version() string {
+	switch d {
+	case glslCompat:
+		return compatVersion
+	case glsl330:
+		return "#version 330 core"
+	}
+	return "#version 150 core"
+}
+
+// fragOutput returns the declaration of the fragment shader output of core
+// dialects.
+//
+func (d glslDialect) fragOutput() string {
+	if d == glsl330 {
+		return "layout(location = 0) out vec4 fragColor;"
+	}
+	return "out vec4 fragColor;"
+}
+
+// qualifiers returns the qualifiers of vertex shader inputs and outputs.
+//
+func (d glslDialect) qualifiers() (in, out string) {
+	if d == glslCompat {
+		return "attribute", "varying"
+	}
+	return "in", "out"
+}
+
+// texture returns the name of the texture sampling function.
+//
+func (d glslDialect) texture() string {
+	if d == glslCompat {
+		return "texture2D"
+	}
+	return "texture"
+}
+
+// vertexShaderCore is the source of the default vertex shader for core
+// profile contexts, without the #version directive.
+//
+var vertexShaderCore = `
+in vec4 aPos;
+in vec4 aColor;
+in float aTexIndex;
+in vec4 aWrap;
+
+out vec4 vTexColor;
+out vec2 vTexCoords;
+out float vTexIndex;
+out vec4 vWrap;
+
+uniform mat4 uProjection;
+
+void main()
+{
+	gl_Position = uProjection * vec4(aPos.xy, 0.0, 1.0);
+    vTexColor = aColor;
+    vTexCoords = aPos.zw;
+    vTexIndex = aTexIndex;
+    vWrap = aWrap;
+}
+`
+
+// fragmentShaderCoreFmt is the fragment shader source format for core profile
+// contexts. The arguments are the #version directive, the output declaration,
+// and the same arguments as fragmentShaderFmt.
+//
+var fragmentShaderCoreFmt = `%s
+in vec4 vTexColor;
+in vec2 vTexCoords;
+in float vTexIndex;
+
+%s
+
+uniform sampler2D uTextures[%d];
+%s
+void main()
+{
+    vec4 texColor;
+%s
+    fragColor = vTexColor * texColor;
+}
+`
+
+// vertexShaderInstancedFmt is the source format of the vertex shader of the
+// instanced batch. The arguments are the #version directive, and the
+// qualifiers of inputs and outputs. Quads are expanded from a per-instance
+// record and aCorner, which goes from (0, 0) at the bottom left corner to
+// (1, 1) at the top right.
+//
+var vertexShaderInstancedFmt = `%[1]s
+%[2]s vec2 aCorner;
+%[2]s vec4 aPosScale;  // position in xy, size times scale in zw
+%[2]s vec4 aOffsetRot; // origin times scale in xy, rotation in z, texture index in w
+%[2]s vec4 aUV;        // texture region as in Drawable.UV: left, bottom, right, top
+%[2]s vec4 aColor;
+
+%[3]s vec4 vTexColor;
+%[3]s vec2 vTexCoords;
+%[3]s float vTexIndex;
+
+uniform mat4 uProjection;
+
+void main()
+{
+    vec2 p = aCorner * aPosScale.zw - aOffsetRot.xy;
+    float s = sin(aOffsetRot.z);
+    float c = cos(aOffsetRot.z);
+    p = aPosScale.xy + vec2(c*p.x - s*p.y, s*p.x + c*p.y);
+    gl_Position = uProjection * vec4(p, 0.0, 1.0);
+    vTexColor = aColor;
+    vTexCoords = vec2(mix(aUV.x, aUV.z, aCorner.x), mix(aUV.w, aUV.y, aCorner.y));
+    vTexIndex = aOffsetRot.w;
+}
+`
@@ -2,12 +2,21 @@
 
 package grog
 
+// compatVersion is the #version directive of shaders in the glslCompat
+// dialect.
+//
+const compatVersion = "#version 130"
+
 var vertexShader = []byte(`#version 130
 attribute vec4 aPos;
 attribute vec4 aColor;
+attribute float aTexIndex;
+attribute vec4 aWrap;
 
 varying vec4 vTexColor;
 varying vec2 vTexCoords;
+varying float vTexIndex;
+varying vec4 vWrap;
 
 uniform mat4 uProjection;
 
@@ -16,19 +25,29 @@ void main()
 	gl_Position = uProjection * vec4(aPos.xy, 0.0, 1.0);
     vTexColor = aColor;
     vTexCoords = aPos.zw;
+    vTexIndex = aTexIndex;
+    vWrap = aWrap;
 }
 `)
 
-var fragmentShader = []byte(`#version 130
+// fragmentShaderFmt is the fragment shader source format. The arguments are
+// the number of texture units, additional declarations, and the code setting
+// texColor by sampling the texture unit selected by vTexIndex. See
+// fragmentShaderWith.
+//
+var fragmentShaderFmt = `#version 130
 precision mediump float;
   
 varying vec4 vTexColor;
 varying vec2 vTexCoords;
+varying float vTexIndex;
 
-uniform sampler2D uTexture;
-
+uniform sampler2D uTextures[%d];
+%s
 void main()
 {
-    gl_FragColor = vTexColor * texture2D(uTexture, vTexCoords);
+    vec4 texColor;
+%s
+    gl_FragColor = vTexColor * texColor;
 }
-`)
+`
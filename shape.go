@@ -0,0 +1,499 @@
+package grog
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/db47h/grog/gl"
+)
+
+// LineCap selects how the ends of open lines are drawn.
+//
+type LineCap int
+
+// Line caps.
+//
+const (
+	CapButt   LineCap = iota // lines end at their end points
+	CapSquare                // lines are extended by half their width
+	CapRound                 // lines end with a half circle
+)
+
+// LineJoin selects how consecutive line segments are joined.
+//
+type LineJoin int
+
+// Line joins.
+//
+const (
+	JoinMiter LineJoin = iota // sharp corners, beveled past the miter limit
+	JoinBevel                 // cut corners
+	JoinRound                 // rounded corners
+)
+
+// DefaultMiterLimit is the miter limit used when ShapeDrawer.MiterLimit is 0.
+//
+const DefaultMiterLimit = 4
+
+// A ShapeDrawer draws lines, rectangles, ellipses, arcs and polygons with a
+// ShapeRenderer. Shapes are drawn in world coordinates with the renderer's
+// built-in white texture, so they batch together with sprites.
+//
+// Outlines are drawn by joining thick line segments. With translucent colors,
+// areas where segments overlap (on the inner side of joins, or where a line
+// crosses itself) are blended more than once.
+//
+// The zero value is ready to use. A ShapeDrawer is not safe for concurrent use
+// by multiple goroutines.
+//
+type ShapeDrawer struct {
+	// Segments is the number of segments used to approximate a full circle or
+	// ellipse. Arcs and round joins use a proportional number of segments. If
+	// 0, the number of segments is computed from the radius.
+	Segments int
+	// Cap is the line cap used for open lines and arcs.
+	Cap LineCap
+	// Join is the line join used between line segments.
+	Join LineJoin
+	// MiterLimit is the maximum ratio of the miter length to half the line
+	// width. Miter joins exceeding this limit are beveled. If 0,
+	// DefaultMiterLimit is used.
+	MiterLimit float32
+	// Layer is the layer in which shapes are drawn by renderers that
+	// implement LayeredMeshRenderer. See LayeredRenderer.
+	Layer int
+
+	c    gl.Color
+	v    []Vertex
+	pts  []Point
+	path []Point
+	idx  []int
+}
+
+// FillRect draws a filled rectangle with corners p0 and p1.
+//
+func (s *ShapeDrawer) FillRect(r ShapeRenderer, p0, p1 Point, c color.Color) {
+	s.setColor(c)
+	s.triangle(p0, Pt(p1.X, p0.Y), Pt(p0.X, p1.Y))
+	s.triangle(Pt(p0.X, p1.Y), Pt(p1.X, p0.Y), p1)
+	s.flush(r)
+}
+
+// StrokeRect draws the outline of the rectangle with corners p0 and p1.
+//
+func (s *ShapeDrawer) StrokeRect(r ShapeRenderer, p0, p1 Point, width float32, c color.Color) {
+	s.pts = append(s.pts[:0], p0, Pt(p1.X, p0.Y), p1, Pt(p0.X, p1.Y))
+	s.setColor(c)
+	s.stroke(s.pts, true, width/2)
+	s.flush(r)
+}
+
+// Line draws a line from p0 to p1.
+//
+func (s *ShapeDrawer) Line(r ShapeRenderer, p0, p1 Point, width float32, c color.Color) {
+	s.pts = append(s.pts[:0], p0, p1)
+	s.setColor(c)
+	s.stroke(s.pts, false, width/2)
+	s.flush(r)
+}
+
+// Polyline draws connected line segments through the given points.
+//
+func (s *ShapeDrawer) Polyline(r ShapeRenderer, pts []Point, width float32, c color.Color) {
+	s.setColor(c)
+	s.stroke(pts, false, width/2)
+	s.flush(r)
+}
+
+// StrokePolygon draws the outline of a polygon. The polygon is closed
+// automatically.
+//
+func (s *ShapeDrawer) StrokePolygon(r ShapeRenderer, pts []Point, width float32, c color.Color) {
+	s.setColor(c)
+	s.stroke(pts, true, width/2)
+	s.flush(r)
+}
+
+// FillPolygon draws a filled polygon. The polygon can be convex or concave but
+// its edges must not cross each other.
+//
+func (s *ShapeDrawer) FillPolygon(r ShapeRenderer, pts []Point, c color.Color) {
+	s.setColor(c)
+	s.triangulate(pts)
+	s.flush(r)
+}
+
+// FillCircle draws a filled circle.
+//
+func (s *ShapeDrawer) FillCircle(r ShapeRenderer, center Point, radius float32, c color.Color) {
+	s.FillEllipse(r, center, Pt(radius, radius), c)
+}
+
+// StrokeCircle draws the outline of a circle.
+//
+func (s *ShapeDrawer) StrokeCircle(r ShapeRenderer, center Point, radius, width float32, c color.Color) {
+	s.StrokeEllipse(r, center, Pt(radius, radius), width, c)
+}
+
+// FillEllipse draws a filled ellipse with the given horizontal and vertical
+// radii.
+//
+func (s *ShapeDrawer) FillEllipse(r ShapeRenderer, center, radii Point, c color.Color) {
+	s.setColor(c)
+	s.arc(center, radii, 0, 2*math.Pi)
+	s.fan(center, s.pts)
+	s.flush(r)
+}
+
+// StrokeEllipse draws the outline of an ellipse with the given horizontal and
+// vertical radii.
+//
+func (s *ShapeDrawer) StrokeEllipse(r ShapeRenderer, center, radii Point, width float32, c color.Color) {
+	s.setColor(c)
+	s.arc(center, radii, 0, 2*math.Pi)
+	s.stroke(s.pts[:len(s.pts)-1], true, width/2)
+	s.flush(r)
+}
+
+// Arc draws an elliptic arc from angle start to angle end, in radians. Angles
+// increase from the positive x axis towards the positive y axis.
+//
+func (s *ShapeDrawer) Arc(r ShapeRenderer, center, radii Point, start, end, width float32, c color.Color) {
+	s.setColor(c)
+	s.arc(center, radii, start, end)
+	s.stroke(s.pts, false, width/2)
+	s.flush(r)
+}
+
+// FillArc draws a filled elliptic sector (a pie slice) from angle start to
+// angle end, in radians.
+//
+func (s *ShapeDrawer) FillArc(r ShapeRenderer, center, radii Point, start, end float32, c color.Color) {
+	s.setColor(c)
+	s.arc(center, radii, start, end)
+	s.fan(center, s.pts)
+	s.flush(r)
+}
+
+func (s *ShapeDrawer) setColor(c color.Color) {
+	s.c = vertexColor(c)
+}
+
+// vertexColor converts c to a vertex color. A nil color is opaque white.
+//
+func vertexColor(c color.Color) gl.Color {
+	if c == nil {
+		return gl.Color{R: 1, G: 1, B: 1, A: 1}
+	}
+	return gl.ColorModel.Convert(c).(gl.Color)
+}
+
+func (s *ShapeDrawer) triangle(a, b, c Point) {
+	s.v = append(s.v, Vertex{Pos: a, Color: s.c}, Vertex{Pos: b, Color: s.c}, Vertex{Pos: c, Color: s.c})
+}
+
+func (s *ShapeDrawer) flush(r ShapeRenderer) {
+	if lr, ok := r.(layeredMeshDrawer); ok && s.Layer != 0 {
+		lr.DrawTrianglesLayer(s.Layer, nil, s.v)
+	} else {
+		r.DrawTriangles(nil, s.v)
+	}
+	s.v = s.v[:0]
+}
+
+// segments returns the number of segments to use for an arc of the given
+// radius and sweep angle.
+//
+func (s *ShapeDrawer) segments(radius, sweep float32) int {
+	n := s.Segments
+	if n <= 0 {
+		// keep the distance between the arc and its segments under a quarter
+		// of a unit.
+		const tolerance = 0.25
+		n = 8
+		if radius > tolerance {
+			a := 2 * math.Acos(1-tolerance/float64(radius))
+			n = int(math.Ceil(2 * math.Pi / a))
+		}
+		if n < 8 {
+			n = 8
+		}
+		if n > 1024 {
+			n = 1024
+		}
+	}
+	n = int(math.Ceil(float64(n) * math.Abs(float64(sweep)) / (2 * math.Pi)))
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+// arc sets s.pts to the points of an elliptic arc.
+//
+func (s *ShapeDrawer) arc(center, radii Point, start, end float32) {
+	rmax := radii.X
+	if radii.Y > rmax {
+		rmax = radii.Y
+	}
+	sweep := end - start
+	n := s.segments(rmax, sweep)
+	s.pts = s.pts[:0]
+	for i := 0; i <= n; i++ {
+		a := float64(start + sweep*float32(i)/float32(n))
+		s.pts = append(s.pts, Pt(center.X+radii.X*float32(math.Cos(a)), center.Y+radii.Y*float32(math.Sin(a))))
+	}
+}
+
+// fan adds the triangles of a fan centered on c.
+//
+func (s *ShapeDrawer) fan(c Point, pts []Point) {
+	for i := 1; i < len(pts); i++ {
+		s.triangle(c, pts[i-1], pts[i])
+	}
+}
+
+// stroke adds the triangles of a thick line through pts. hw is half the line
+// width.
+//
+func (s *ShapeDrawer) stroke(pts []Point, closed bool, hw float32) {
+	if hw <= 0 {
+		return
+	}
+	// remove zero length segments
+	path := s.path[:0]
+	for _, p := range pts {
+		if len(path) == 0 || !p.Eq(path[len(path)-1]) {
+			path = append(path, p)
+		}
+	}
+	if closed && len(path) > 1 && path[0].Eq(path[len(path)-1]) {
+		path = path[:len(path)-1]
+	}
+	s.path = path
+	if len(path) < 2 {
+		return
+	}
+	if len(path) < 3 {
+		closed = false
+	}
+
+	n := len(path) - 1
+	if closed {
+		n++
+	}
+	for i := 0; i < n; i++ {
+		a, b := path[i], path[(i+1)%len(path)]
+		d := unit(b.Sub(a))
+		if !closed && s.Cap == CapSquare {
+			if i == 0 {
+				a = a.Sub(d.Mul(hw))
+			}
+			if i == n-1 {
+				b = b.Add(d.Mul(hw))
+			}
+		}
+		nm := normal(d).Mul(hw)
+		s.triangle(a.Add(nm), b.Add(nm), a.Sub(nm))
+		s.triangle(a.Sub(nm), b.Add(nm), b.Sub(nm))
+	}
+
+	// joins
+	first, last := 1, len(path)-1
+	if closed {
+		first, last = 0, len(path)
+	}
+	for i := first; i < last; i++ {
+		p := path[i]
+		prev := path[(i+len(path)-1)%len(path)]
+		next := path[(i+1)%len(path)]
+		s.join(p, unit(p.Sub(prev)), unit(next.Sub(p)), hw)
+	}
+
+	// caps
+	if !closed && s.Cap == CapRound {
+		s.roundCap(path[0], unit(path[0].Sub(path[1])), hw)
+		s.roundCap(path[len(path)-1], unit(path[len(path)-1].Sub(path[len(path)-2])), hw)
+	}
+}
+
+// join adds the triangles joining two segments of a thick line at point p.
+// d0 and d1 are the directions of the incoming and outgoing segments.
+//
+func (s *ShapeDrawer) join(p, d0, d1 Point, hw float32) {
+	cross := d0.X*d1.Y - d0.Y*d1.X
+	dot := d0.X*d1.X + d0.Y*d1.Y
+	if cross == 0 && dot > 0 {
+		return
+	}
+	// offsets to the outer side of the turn
+	o0, o1 := normal(d0).Mul(hw), normal(d1).Mul(hw)
+	if cross > 0 {
+		o0, o1 = o0.Mul(-1), o1.Mul(-1)
+	}
+	switch s.Join {
+	case JoinRound:
+		a0 := math.Atan2(float64(o0.Y), float64(o0.X))
+		sweep := math.Atan2(float64(o1.Y), float64(o1.X)) - a0
+		if sweep > math.Pi {
+			sweep -= 2 * math.Pi
+		} else if sweep < -math.Pi {
+			sweep += 2 * math.Pi
+		}
+		s.pie(p, hw, a0, sweep)
+	case JoinMiter:
+		limit := s.MiterLimit
+		if limit <= 0 {
+			limit = DefaultMiterLimit
+		}
+		k := hw*hw + o0.X*o1.X + o0.Y*o1.Y
+		if k > 0 {
+			m := o0.Add(o1).Mul(hw * hw / k)
+			if m.X*m.X+m.Y*m.Y <= limit*limit*hw*hw {
+				s.triangle(p, p.Add(o0), p.Add(m))
+				s.triangle(p, p.Add(m), p.Add(o1))
+				return
+			}
+		}
+		fallthrough
+	default:
+		s.triangle(p, p.Add(o0), p.Add(o1))
+	}
+}
+
+// roundCap adds a half circle at the end p of a thick line. d is the outward
+// direction of the line at p.
+//
+func (s *ShapeDrawer) roundCap(p, d Point, hw float32) {
+	nm := normal(d)
+	s.pie(p, hw, math.Atan2(float64(nm.Y), float64(nm.X)), -math.Pi)
+}
+
+// pie adds the triangles of a circular sector centered on p.
+//
+func (s *ShapeDrawer) pie(p Point, radius float32, start, sweep float64) {
+	n := s.segments(radius, float32(sweep))
+	q := Pt(p.X+radius*float32(math.Cos(start)), p.Y+radius*float32(math.Sin(start)))
+	for i := 1; i <= n; i++ {
+		a := start + sweep*float64(i)/float64(n)
+		r := Pt(p.X+radius*float32(math.Cos(a)), p.Y+radius*float32(math.Sin(a)))
+		s.triangle(p, q, r)
+		q = r
+	}
+}
+
+// triangulate adds the triangles of a simple polygon, using ear clipping.
+//
+func (s *ShapeDrawer) triangulate(pts []Point) {
+	path := s.path[:0]
+	for _, p := range pts {
+		if len(path) == 0 || !p.Eq(path[len(path)-1]) {
+			path = append(path, p)
+		}
+	}
+	if len(path) > 1 && path[0].Eq(path[len(path)-1]) {
+		path = path[:len(path)-1]
+	}
+	s.path = path
+	if len(path) < 3 {
+		return
+	}
+
+	// orientation: make convex vertices have a positive cross product.
+	var area float32
+	for i := range path {
+		a, b := path[i], path[(i+1)%len(path)]
+		area += a.X*b.Y - b.X*a.Y
+	}
+	if area == 0 {
+		return
+	}
+	var orient float32 = 1
+	if area < 0 {
+		orient = -1
+	}
+
+	idx := s.idx[:0]
+	for i := range path {
+		idx = append(idx, i)
+	}
+	for i := 0; len(idx) > 3; {
+		found := false
+		for tries := 0; tries < len(idx); tries++ {
+			i %= len(idx)
+			ia, ib, ic := idx[(i+len(idx)-1)%len(idx)], idx[i], idx[(i+1)%len(idx)]
+			a, b, c := path[ia], path[ib], path[ic]
+			cr := orient * cross3(a, b, c)
+			if cr < 0 {
+				// reflex vertex
+				i++
+				continue
+			}
+			if cr > 0 && !s.isEar(idx, ia, ib, ic) {
+				i++
+				continue
+			}
+			if cr > 0 {
+				s.triangle(a, b, c)
+			}
+			// clip the ear, or remove the collinear vertex b.
+			idx = append(idx[:i], idx[i+1:]...)
+			found = true
+			break
+		}
+		if !found {
+			// self-intersecting polygon: give up.
+			break
+		}
+	}
+	if len(idx) == 3 {
+		a, b, c := path[idx[0]], path[idx[1]], path[idx[2]]
+		if cross3(a, b, c) != 0 {
+			s.triangle(a, b, c)
+		}
+	}
+	s.idx = idx
+}
+
+// isEar returns true if no vertex of the polygon lies within triangle
+// (a, b, c).
+//
+func (s *ShapeDrawer) isEar(idx []int, ia, ib, ic int) bool {
+	a, b, c := s.path[ia], s.path[ib], s.path[ic]
+	for _, j := range idx {
+		if j == ia || j == ib || j == ic {
+			continue
+		}
+		p := s.path[j]
+		if p.Eq(a) || p.Eq(b) || p.Eq(c) {
+			continue
+		}
+		d0, d1, d2 := cross3(a, b, p), cross3(b, c, p), cross3(c, a, p)
+		neg := d0 < 0 || d1 < 0 || d2 < 0
+		pos := d0 > 0 || d1 > 0 || d2 > 0
+		if !(neg && pos) {
+			return false
+		}
+	}
+	return true
+}
+
+// cross3 returns the cross product of (b - a) and (c - b).
+//
+func cross3(a, b, c Point) float32 {
+	return (b.X-a.X)*(c.Y-b.Y) - (b.Y-a.Y)*(c.X-b.X)
+}
+
+func unit(p Point) Point {
+	l := float32(math.Hypot(float64(p.X), float64(p.Y)))
+	if l == 0 {
+		return p
+	}
+	return p.Div(l)
+}
+
+// normal returns d rotated by 90 degrees.
+//
+func normal(d Point) Point {
+	return Point{-d.Y, d.X}
+}
@@ -0,0 +1,160 @@
+package grog
+
+import (
+	"math"
+	"testing"
+)
+
+// polygonArea returns the signed area of a polygon, times 2.
+//
+func polygonArea(pts []Point) float32 {
+	var a float32
+	for i := range pts {
+		p, q := pts[i], pts[(i+1)%len(pts)]
+		a += p.X*q.Y - q.X*p.Y
+	}
+	return a
+}
+
+// insidePolygon returns true if p is inside the polygon pts.
+//
+func insidePolygon(p Point, pts []Point) bool {
+	in := false
+	for i, j := 0, len(pts)-1; i < len(pts); j, i = i, i+1 {
+		a, b := pts[i], pts[j]
+		if (a.Y > p.Y) != (b.Y > p.Y) && p.X < (b.X-a.X)*(p.Y-a.Y)/(b.Y-a.Y)+a.X {
+			in = !in
+		}
+	}
+	return in
+}
+
+func reversed(pts []Point) []Point {
+	r := make([]Point, len(pts))
+	for i, p := range pts {
+		r[len(pts)-1-i] = p
+	}
+	return r
+}
+
+func TestShapeDrawer_triangulate(t *testing.T) {
+	polygons := []struct {
+		name string
+		pts  []Point
+	}{
+		{"square", []Point{{0, 0}, {10, 0}, {10, 10}, {0, 10}}},
+		{"L", []Point{{0, 0}, {4, 0}, {4, 6}, {10, 6}, {10, 10}, {0, 10}}},
+		{"arrow", []Point{{0, 0}, {10, 5}, {0, 10}, {3, 5}}},
+		{"comb", []Point{{0, 0}, {2, 0}, {2, 8}, {3, 8}, {3, 0}, {5, 0}, {5, 8}, {6, 8}, {6, 0}, {8, 0}, {8, 10}, {0, 10}}},
+		{"star", []Point{{5, 0}, {6, 4}, {10, 4}, {7, 6}, {8, 10}, {5, 7}, {2, 10}, {3, 6}, {0, 4}, {4, 4}}},
+		{"collinear", []Point{{0, 0}, {5, 0}, {10, 0}, {10, 10}, {5, 5}, {0, 10}}},
+	}
+	for _, poly := range polygons {
+		for _, pts := range [][]Point{poly.pts, reversed(poly.pts)} {
+			var s ShapeDrawer
+			s.triangulate(pts)
+			if len(s.v)%3 != 0 {
+				t.Fatalf("%s: %d vertices", poly.name, len(s.v))
+			}
+			area := polygonArea(pts)
+			var sum float32
+			for i := 0; i < len(s.v); i += 3 {
+				tri := []Point{s.v[i].Pos, s.v[i+1].Pos, s.v[i+2].Pos}
+				ta := polygonArea(tri)
+				if ta == 0 || (ta > 0) != (area > 0) {
+					t.Errorf("%s: triangle %v has area %g, polygon has area %g", poly.name, tri, ta/2, area/2)
+				}
+				c := tri[0].Add(tri[1]).Add(tri[2]).Div(3)
+				if !insidePolygon(c, pts) {
+					t.Errorf("%s: triangle %v is outside of the polygon", poly.name, tri)
+				}
+				sum += ta
+			}
+			if math.Abs(float64(sum-area)) > 1e-3 {
+				t.Errorf("%s: triangles cover an area of %g, want %g", poly.name, sum/2, area/2)
+			}
+		}
+	}
+}
+
+func TestShapeDrawer_triangulateDegenerate(t *testing.T) {
+	for _, pts := range [][]Point{
+		nil,
+		{{0, 0}, {1, 1}},
+		{{0, 0}, {1, 1}, {2, 2}},
+		{{0, 0}, {1, 0}, {1, 1}, {0, 0}},
+	} {
+		var s ShapeDrawer
+		s.triangulate(pts)
+		if n := len(s.v) / 3; n > 1 {
+			t.Errorf("%v: got %d triangles", pts, n)
+		}
+	}
+}
+
+// hasVertex returns true if p is one of the vertices v.
+//
+func hasVertex(v []Vertex, p Point) bool {
+	for i := range v {
+		if math.Abs(float64(v[i].Pos.X-p.X)) < 1e-4 && math.Abs(float64(v[i].Pos.Y-p.Y)) < 1e-4 {
+			return true
+		}
+	}
+	return false
+}
+
+func maxX(v []Vertex) float32 {
+	m := v[0].Pos.X
+	for i := range v {
+		m = maxf(m, v[i].Pos.X)
+	}
+	return m
+}
+
+func TestShapeDrawer_joins(t *testing.T) {
+	corner := []Point{{0, 0}, {10, 0}, {10, 10}}
+
+	s := ShapeDrawer{Join: JoinMiter}
+	s.stroke(corner, false, 1)
+	if !hasVertex(s.v, Pt(11, -1)) {
+		t.Errorf("miter join: missing miter tip (11, -1) in %v", s.v)
+	}
+	if n := len(s.v) / 3; n != 6 {
+		t.Errorf("miter join: got %d triangles, want 6", n)
+	}
+
+	s = ShapeDrawer{Join: JoinBevel}
+	s.stroke(corner, false, 1)
+	if hasVertex(s.v, Pt(11, -1)) {
+		t.Errorf("bevel join: unexpected miter tip (11, -1)")
+	}
+	join := s.v[len(s.v)-3:]
+	for _, p := range []Point{{10, 0}, {10, -1}, {11, 0}} {
+		if !hasVertex(join, p) {
+			t.Errorf("bevel join: missing vertex %v in %v", p, join)
+		}
+	}
+	if n := len(s.v) / 3; n != 5 {
+		t.Errorf("bevel join: got %d triangles, want 5", n)
+	}
+
+	// sharp turn: the miter is about 20 times half the line width
+	sharp := []Point{{0, 0}, {10, 0}, {0, 1}}
+	s = ShapeDrawer{Join: JoinMiter}
+	s.stroke(sharp, false, 1)
+	if x := maxX(s.v); x > 11+1e-4 {
+		t.Errorf("miter join past the default limit: max x = %g, want <= 11", x)
+	}
+	s = ShapeDrawer{Join: JoinMiter, MiterLimit: 100}
+	s.stroke(sharp, false, 1)
+	if x := maxX(s.v); x < 25 {
+		t.Errorf("miter join within the limit: max x = %g, want >= 25", x)
+	}
+
+	// straight line: no join
+	s = ShapeDrawer{Join: JoinMiter}
+	s.stroke([]Point{{0, 0}, {5, 0}, {10, 0}}, false, 1)
+	if n := len(s.v) / 3; n != 4 {
+		t.Errorf("collinear segments: got %d triangles, want 4", n)
+	}
+}
@@ -0,0 +1,92 @@
+package grog
+
+import (
+	"math"
+
+	"github.com/db47h/grog/gl"
+)
+
+// A snapper snaps sprites to whole frame buffer pixels.
+//
+type snapper struct {
+	enabled bool
+	valid   bool // false if no camera is set or if it is not axis-aligned
+	cam     Camera
+	ax, cx  float32 // pixel x = ax*wx + cx
+	ay, cy  float32 // pixel y = ay*wy + cy, y axis pointing upwards
+}
+
+// setCamera sets the camera used to convert world coordinates to pixels.
+//
+func (s *snapper) setCamera(c Camera) {
+	s.cam = c
+	s.update()
+}
+
+// setEnabled enables or disables snapping.
+//
+func (s *snapper) setEnabled(enabled bool) {
+	s.enabled = enabled
+	s.update()
+}
+
+// update computes the world to pixel transform of the current camera. For
+// cameras with a known frame buffer (see cameraFb), the frame buffer size is
+// that of this frame buffer; for other cameras, it is the size of the current
+// GL viewport.
+//
+func (s *snapper) update() {
+	s.valid = false
+	if !s.enabled || s.cam == nil {
+		return
+	}
+	p := s.cam.ProjectionMatrix()
+	if p[1] != 0 || p[4] != 0 || p[0] == 0 || p[5] == 0 {
+		return
+	}
+	var w, h float32
+	if fb := cameraFb(s.cam); fb != nil {
+		sz := fb.Size()
+		w, h = float32(sz.X), float32(sz.Y)
+	} else {
+		var vp [4]int32
+		gl.GetIntegerv(gl.GL_VIEWPORT, &vp[0])
+		w, h = float32(vp[2]), float32(vp[3])
+	}
+	s.ax, s.cx = p[0]*w/2, (p[12]+1)*w/2
+	s.ay, s.cy = p[5]*h/2, (p[13]+1)*h/2
+	s.valid = true
+}
+
+// sprite returns the position and scale to use for a sprite so that its edges
+// fall on pixel boundaries. Rotated sprites are not snapped.
+//
+func (s *snapper) sprite(d Drawable, dp, scale Point, rot float32) (Point, Point) {
+	if !s.valid || rot != 0 {
+		return dp, scale
+	}
+	o := d.Origin()
+	sz := d.Size()
+	dp.X, scale.X = snapAxis(dp.X, scale.X, float32(o.X), float32(sz.X), s.ax, s.cx)
+	dp.Y, scale.Y = snapAxis(dp.Y, scale.Y, float32(o.Y), float32(sz.Y), s.ay, s.cy)
+	return dp, scale
+}
+
+// snapAxis snaps a sprite along one axis, given the position p, scale, origin
+// and size of the sprite, and the world to pixel transform a*x + c.
+//
+func snapAxis(p, scale, o, sz, a, c float32) (float32, float32) {
+	if sz == 0 {
+		return p, scale
+	}
+	x0 := p - o*scale
+	x1 := x0 + sz*scale
+	x0 = (round(a*x0+c) - c) / a
+	x1 = (round(a*x1+c) - c) / a
+	scale = (x1 - x0) / sz
+	return x0 + o*scale, scale
+}
+
+func round(x float32) float32 {
+	return float32(math.Floor(float64(x) + 0.5))
+}
@@ -0,0 +1,442 @@
+// The soft package provides a software implementation of grog.BatchRenderer
+// that draws into an *image.RGBA. It does not need an OpenGL context and is
+// mainly intended for testing and headless rendering.
+//
+// Drawables must use textures from this package (see Texture): drawing an
+// OpenGL texture panics. Text can be drawn by creating a TextDrawer with
+// grog.NewTextDrawerFunc and NewGlyphTexture.
+//
+package soft
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"github.com/db47h/grog"
+	"github.com/db47h/grog/gl"
+)
+
+// vertex is a vertex in framebuffer pixel coordinates.
+//
+type vertex struct {
+	x, y       float32
+	u, v       float32
+	r, g, b, a float32
+}
+
+// A Renderer is a grog.BatchRenderer that rasterizes draw calls into an
+// *image.RGBA. Draw calls are rendered immediately, so Begin, Flush and End are
+// no-ops.
+//
+// The destination image is the framebuffer: Camera and View coordinates must be
+// computed for a FrameBuffer of the same size as the image. Like the OpenGL
+// batches, colors are alpha premultiplied and blending is done according to
+// the current grog.BlendMode.
+//
+type Renderer struct {
+	dst   *image.RGBA
+	proj  [16]float32
+	clip  image.Rectangle
+	clear gl.Color
+	mode  grog.BlendMode
+	bf    [4]uint32 // blend factors: src RGB, dst RGB, src alpha, dst alpha
+}
+
+// NewRenderer returns a new Renderer that draws into dst.
+//
+func NewRenderer(dst *image.RGBA) *Renderer {
+	r := &Renderer{
+		dst:  dst,
+		proj: [16]float32{0: 1, 5: 1, 10: 1, 15: 1},
+		clip: dst.Rect,
+	}
+	r.SetBlendMode(grog.BlendPremultipliedAlpha)
+	return r
+}
+
+// SetBlendMode sets the blend mode for subsequent draw calls.
+//
+func (r *Renderer) SetBlendMode(m grog.BlendMode) {
+	r.mode = m
+	r.bf[0], r.bf[1], r.bf[2], r.bf[3] = m.BlendFunc()
+}
+
+// Begin is a no-op.
+//
+func (r *Renderer) Begin() {}
+
+// Flush is a no-op.
+//
+func (r *Renderer) Flush() {}
+
+// End is a no-op.
+//
+func (r *Renderer) End() {}
+
+// Close is a no-op.
+//
+func (r *Renderer) Close() {}
+
+// Camera sets the camera for world to screen transforms and clipping region.
+//
+func (r *Renderer) Camera(c grog.Camera) {
+	r.proj = c.ProjectionMatrix()
+	gr := c.GLRect()
+	b := r.dst.Rect
+	h := b.Dy()
+	r.clip = image.Rect(gr.Min.X, h-gr.Max.Y, gr.Max.X, h-gr.Min.Y).Add(b.Min).Intersect(b)
+}
+
+// Clear clears the clipping region of the current camera with color c. If c
+// is nil, the last color used is reused.
+//
+func (r *Renderer) Clear(c color.Color) {
+	if c != nil {
+		r.clear = gl.ColorModel.Convert(c).(gl.Color)
+	}
+	cc := color.RGBA{quantize(r.clear.R), quantize(r.clear.G), quantize(r.clear.B), quantize(r.clear.A)}
+	draw.Draw(r.dst, r.clip, &image.Uniform{cc}, image.ZP, draw.Src)
+}
+
+// Draw draws d. See grog.Renderer.
+//
+func (r *Renderer) Draw(d grog.Drawable, dp, scale grog.Point, rot float32, c color.Color) {
+	t := lookupTexture(d.NativeID())
+	if t == nil {
+		return
+	}
+
+	var rf, gf, bf, af float32 = 1, 1, 1, 1
+	if c != nil {
+		c := gl.ColorModel.Convert(c).(gl.Color)
+		rf, gf, bf, af = c.R, c.G, c.B, c.A
+	}
+
+	var m0, m1, m3, m4 float32 = 1, 0, 0, 1
+	if rot != 0 {
+		sin, cos := float32(math.Sin(float64(rot))), float32(math.Cos(float64(rot)))
+		m0, m1, m3, m4 = cos, sin, -sin, cos
+	}
+
+	o := d.Origin()
+	tx, ty := float32(o.X)*scale.X, float32(o.Y)*scale.Y
+	m6, m7 := dp.X-m0*tx-m3*ty, dp.Y-m1*tx-m4*ty
+
+	sz := d.Size()
+	sX, sY := scale.X*float32(sz.X), scale.Y*float32(sz.Y)
+	m0 *= sX
+	m1 *= sX
+	m3 *= sY
+	m4 *= sY
+
+	uv := d.UV()
+	q := [4]vertex{
+		// top left
+		r.vertex(m3+m6, m4+m7, uv[0], uv[1], rf, gf, bf, af),
+		// top right
+		r.vertex(m0+m3+m6, m1+m4+m7, uv[2], uv[1], rf, gf, bf, af),
+		// bottom left
+		r.vertex(m6, m7, uv[0], uv[3], rf, gf, bf, af),
+		// bottom right
+		r.vertex(m0+m6, m1+m7, uv[2], uv[3], rf, gf, bf, af),
+	}
+	r.quad(t, &q)
+}
+
+// vertex returns a vertex for world coordinates x, y transformed to
+// framebuffer pixel coordinates.
+//
+func (r *Renderer) vertex(x, y, u, v, cr, cg, cb, ca float32) vertex {
+	p := &r.proj
+	gx := p[0]*x + p[4]*y + p[12]
+	gy := p[1]*x + p[5]*y + p[13]
+	b := r.dst.Rect
+	return vertex{
+		x: float32(b.Min.X) + (gx+1)*float32(b.Dx())/2,
+		y: float32(b.Min.Y) + (1-gy)*float32(b.Dy())/2,
+		u: u, v: v,
+		r: cr, g: cg, b: cb, a: ca,
+	}
+}
+
+// quad draws the two triangles of q in the same order as the OpenGL batches
+// (0, 1, 2) and (2, 1, 3).
+//
+func (r *Renderer) quad(t *Texture, q *[4]vertex) {
+	f := t.filter(&q[0], &q[1], &q[2], nil)
+	r.triangle(t, f, nil, &q[0], &q[1], &q[2])
+	r.triangle(t, f, nil, &q[2], &q[1], &q[3])
+}
+
+// DrawTriangles draws textured triangles. See grog.ShapeRenderer.
+//
+func (r *Renderer) DrawTriangles(d grog.Drawable, v []grog.Vertex) {
+	t := white
+	if d != nil {
+		t = lookupTexture(d.NativeID())
+		if t == nil {
+			return
+		}
+	}
+	for i := 0; i+3 <= len(v); i += 3 {
+		var tri [3]vertex
+		for j := range tri {
+			s := &v[i+j]
+			tri[j] = r.vertex(s.Pos.X, s.Pos.Y, s.UV.X, s.UV.Y, s.Color.R, s.Color.G, s.Color.B, s.Color.A)
+		}
+		var w *[4]float32
+		if v[i].Wrap != ([4]float32{}) {
+			w = &v[i].Wrap
+		}
+		r.triangle(t, t.filter(&tri[0], &tri[1], &tri[2], w), w, &tri[0], &tri[1], &tri[2])
+	}
+}
+
+// DrawQuad draws a textured quad. See grog.MeshRenderer.
+//
+func (r *Renderer) DrawQuad(d grog.Drawable, q *[4]grog.Vertex) {
+	r.DrawTriangles(d, []grog.Vertex{q[0], q[1], q[3], q[3], q[1], q[2]})
+}
+
+// DrawMesh draws a textured triangle mesh. See grog.MeshRenderer.
+//
+func (r *Renderer) DrawMesh(d grog.Drawable, v []grog.Vertex, indices []uint16) {
+	if len(indices)%3 != 0 {
+		panic("number of mesh indices must be a multiple of 3")
+	}
+	t := make([]grog.Vertex, len(indices))
+	for i, j := range indices {
+		if int(j) >= len(v) {
+			panic(fmt.Sprintf("mesh index %d out of range [0, %d)", j, len(v)))
+		}
+		t[i] = v[j]
+	}
+	r.DrawTriangles(d, t)
+}
+
+// edge returns the edge function for the edge a->b at point x, y. Its absolute
+// value is twice the area of the triangle (a, b, (x,y)).
+//
+func edge(a, b *vertex, x, y float32) float32 {
+	return (b.x-a.x)*(y-a.y) - (b.y-a.y)*(x-a.x)
+}
+
+// side returns edge(a, b, x, y), always evaluated in the same vertex order so
+// that side(a, b, x, y) == -side(b, a, x, y) exactly. This guarantees that
+// pixels on an edge shared by two triangles are drawn only once.
+//
+func side(a, b *vertex, x, y float32) float32 {
+	if a.y > b.y || a.y == b.y && a.x > b.x {
+		return -edge(b, a, x, y)
+	}
+	return edge(a, b, x, y)
+}
+
+// topLeft returns true if a->b is a top or left edge of a triangle with
+// positive area.
+//
+func topLeft(a, b *vertex) bool {
+	dx, dy := b.x-a.x, b.y-a.y
+	return dy < 0 || dy == 0 && dx > 0
+}
+
+func inside(w float32, tl bool) bool {
+	return w > 0 || w == 0 && tl
+}
+
+// triangle draws a triangle sampling texture t with filter f. If w is not nil,
+// texture coordinates wrap around within the region w (see grog.Vertex).
+//
+func (r *Renderer) triangle(t *Texture, f grog.TextureFilter, w *[4]float32, v0, v1, v2 *vertex) {
+	area := edge(v0, v1, v2.x, v2.y)
+	if area == 0 {
+		return
+	}
+	if area < 0 {
+		v1, v2 = v2, v1
+		area = -area
+	}
+	bb := image.Rect(
+		int(floor(min3(v0.x, v1.x, v2.x))), int(floor(min3(v0.y, v1.y, v2.y))),
+		int(ceil(max3(v0.x, v1.x, v2.x))), int(ceil(max3(v0.y, v1.y, v2.y))),
+	).Intersect(r.clip)
+	if bb.Empty() {
+		return
+	}
+	tl0, tl1, tl2 := topLeft(v1, v2), topLeft(v2, v0), topLeft(v0, v1)
+	for y := bb.Min.Y; y < bb.Max.Y; y++ {
+		py := float32(y) + .5
+		for x := bb.Min.X; x < bb.Max.X; x++ {
+			px := float32(x) + .5
+			w0, w1, w2 := side(v1, v2, px, py), side(v2, v0, px, py), side(v0, v1, px, py)
+			if !inside(w0, tl0) || !inside(w1, tl1) || !inside(w2, tl2) {
+				continue
+			}
+			w0, w1, w2 = w0/area, w1/area, w2/area
+			u, v := w0*v0.u+w1*v1.u+w2*v2.u, w0*v0.v+w1*v1.v+w2*v2.v
+			if w != nil {
+				u, v = w[0]+(u-floor(u))*(w[2]-w[0]), w[3]+(v-floor(v))*(w[1]-w[3])
+			}
+			sr, sg, sb, sa := t.sample(u, v, f)
+			sr *= w0*v0.r + w1*v1.r + w2*v2.r
+			sg *= w0*v0.g + w1*v1.g + w2*v2.g
+			sb *= w0*v0.b + w1*v1.b + w2*v2.b
+			sa *= w0*v0.a + w1*v1.a + w2*v2.a
+			r.blend(x, y, sr, sg, sb, sa)
+		}
+	}
+}
+
+// blend blends a color into the destination pixel at x, y according to the
+// current blend mode.
+//
+func (r *Renderer) blend(x, y int, sr, sg, sb, sa float32) {
+	o := r.dst.PixOffset(x, y)
+	p := r.dst.Pix[o : o+4 : o+4]
+	if r.mode == grog.BlendPremultipliedAlpha {
+		ia := (1 - sa) / 0xff
+		p[0] = quantize(sr + float32(p[0])*ia)
+		p[1] = quantize(sg + float32(p[1])*ia)
+		p[2] = quantize(sb + float32(p[2])*ia)
+		p[3] = quantize(sa + float32(p[3])*ia)
+		return
+	}
+	src := [4]float32{sr, sg, sb, sa}
+	dst := [4]float32{float32(p[0]) / 0xff, float32(p[1]) / 0xff, float32(p[2]) / 0xff, float32(p[3]) / 0xff}
+	for i := range p {
+		sf, df := r.bf[0], r.bf[1]
+		if i == 3 {
+			sf, df = r.bf[2], r.bf[3]
+		}
+		p[i] = quantize(src[i]*factor(sf, &src, &dst, i) + dst[i]*factor(df, &src, &dst, i))
+	}
+}
+
+// factor returns the value of the OpenGL blend factor f for channel i.
+//
+func factor(f uint32, src, dst *[4]float32, i int) float32 {
+	switch f {
+	case gl.GL_ZERO:
+		return 0
+	case gl.GL_ONE:
+		return 1
+	case gl.GL_SRC_COLOR:
+		return src[i]
+	case gl.GL_ONE_MINUS_SRC_COLOR:
+		return 1 - src[i]
+	case gl.GL_DST_COLOR:
+		return dst[i]
+	case gl.GL_ONE_MINUS_DST_COLOR:
+		return 1 - dst[i]
+	case gl.GL_SRC_ALPHA:
+		return src[3]
+	case gl.GL_ONE_MINUS_SRC_ALPHA:
+		return 1 - src[3]
+	case gl.GL_DST_ALPHA:
+		return dst[3]
+	case gl.GL_ONE_MINUS_DST_ALPHA:
+		return 1 - dst[3]
+	}
+	return 0
+}
+
+// filter returns the texture filter to use for a triangle, depending on whether
+// the texture is minified or magnified. If w is not nil, texture coordinates
+// are given in copies of the region w.
+//
+func (t *Texture) filter(a, b, c *vertex, w *[4]float32) grog.TextureFilter {
+	sz := t.Size()
+	texArea := abs((b.u-a.u)*(c.v-a.v)-(b.v-a.v)*(c.u-a.u)) * float32(sz.X*sz.Y)
+	if w != nil {
+		texArea *= abs((w[2] - w[0]) * (w[1] - w[3]))
+	}
+	if abs(edge(a, b, c.x, c.y)) < texArea {
+		return t.minFilter
+	}
+	return t.magFilter
+}
+
+// sample returns the alpha premultiplied color of the texture at u, v with
+// components in the range [0, 1]. Texture coordinates are clamped to the edge.
+//
+func (t *Texture) sample(u, v float32, f grog.TextureFilter) (r, g, b, a float32) {
+	sz := t.img.Rect.Size()
+	if f != grog.Linear {
+		p := t.texel(int(floor(u*float32(sz.X))), int(floor(v*float32(sz.Y))))
+		return float32(p[0]) / 0xff, float32(p[1]) / 0xff, float32(p[2]) / 0xff, float32(p[3]) / 0xff
+	}
+	fx, fy := u*float32(sz.X)-.5, v*float32(sz.Y)-.5
+	x0, y0 := floor(fx), floor(fy)
+	ax, ay := fx-x0, fy-y0
+	ix, iy := int(x0), int(y0)
+	p00, p10 := t.texel(ix, iy), t.texel(ix+1, iy)
+	p01, p11 := t.texel(ix, iy+1), t.texel(ix+1, iy+1)
+	var c [4]float32
+	for i := range c {
+		top := float32(p00[i])*(1-ax) + float32(p10[i])*ax
+		bot := float32(p01[i])*(1-ax) + float32(p11[i])*ax
+		c[i] = (top*(1-ay) + bot*ay) / 0xff
+	}
+	return c[0], c[1], c[2], c[3]
+}
+
+// texel returns the texel at x, y clamped to the texture bounds.
+//
+func (t *Texture) texel(x, y int) []uint8 {
+	b := t.img.Rect
+	if x < b.Min.X {
+		x = b.Min.X
+	} else if x >= b.Max.X {
+		x = b.Max.X - 1
+	}
+	if y < b.Min.Y {
+		y = b.Min.Y
+	} else if y >= b.Max.Y {
+		y = b.Max.Y - 1
+	}
+	o := t.img.PixOffset(x, y)
+	return t.img.Pix[o : o+4 : o+4]
+}
+
+func quantize(v float32) uint8 {
+	switch {
+	case v <= 0:
+		return 0
+	case v >= 1:
+		return 0xff
+	}
+	return uint8(v*0xff + .5)
+}
+
+func floor(v float32) float32 { return float32(math.Floor(float64(v))) }
+func ceil(v float32) float32  { return float32(math.Ceil(float64(v))) }
+
+func abs(v float32) float32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func min3(a, b, c float32) float32 {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+func max3(a, b, c float32) float32 {
+	if b > a {
+		a = b
+	}
+	if c > a {
+		a = c
+	}
+	return a
+}
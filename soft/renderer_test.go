@@ -0,0 +1,243 @@
+package soft_test
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/db47h/grog"
+	"github.com/db47h/grog/soft"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+var (
+	red    = color.RGBA{0xff, 0, 0, 0xff}
+	green  = color.RGBA{0, 0xff, 0, 0xff}
+	blue   = color.RGBA{0, 0, 0xff, 0xff}
+	yellow = color.RGBA{0xff, 0xff, 0, 0xff}
+)
+
+// newTarget returns a new transparent image of size w x h, a Renderer drawing
+// into it and a view for which world coordinates are image coordinates.
+//
+func newTarget(w, h int) (*image.RGBA, *soft.Renderer, *grog.View) {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	return img, soft.NewRenderer(img), grog.NewScreen(image.Pt(w, h)).View()
+}
+
+// quadrants returns a 4x4 texture with red, green, blue and yellow 2x2
+// quadrants, clockwise from the top left.
+//
+func quadrants() *soft.Texture {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	draw.Draw(img, image.Rect(0, 0, 2, 2), image.NewUniform(red), image.ZP, draw.Src)
+	draw.Draw(img, image.Rect(2, 0, 4, 2), image.NewUniform(green), image.ZP, draw.Src)
+	draw.Draw(img, image.Rect(2, 2, 4, 4), image.NewUniform(blue), image.ZP, draw.Src)
+	draw.Draw(img, image.Rect(0, 2, 2, 4), image.NewUniform(yellow), image.ZP, draw.Src)
+	return soft.TextureFromImage(img)
+}
+
+// compare reports every pixel of got that differs from want.
+//
+func compare(t *testing.T, got, want *image.RGBA) {
+	t.Helper()
+	n := 0
+	for y := want.Rect.Min.Y; y < want.Rect.Max.Y; y++ {
+		for x := want.Rect.Min.X; x < want.Rect.Max.X; x++ {
+			if g, w := got.RGBAAt(x, y), want.RGBAAt(x, y); g != w {
+				if n++; n <= 10 {
+					t.Errorf("pixel (%d, %d) = %v, want %v", x, y, g, w)
+				}
+			}
+		}
+	}
+	if n > 10 {
+		t.Errorf("%d pixels differ", n)
+	}
+}
+
+func TestDraw_sprite(t *testing.T) {
+	img, r, v := newTarget(8, 8)
+	r.Camera(v)
+	r.Draw(quadrants(), grog.Pt(3, 1), grog.Pt(1, 1), 0, nil)
+
+	want := image.NewRGBA(img.Rect)
+	draw.Draw(want, image.Rect(3, 1, 7, 5), quadrants().Image(), image.ZP, draw.Src)
+	compare(t, img, want)
+}
+
+func TestDraw_scaled(t *testing.T) {
+	img, r, v := newTarget(8, 8)
+	r.Camera(v)
+	r.Draw(quadrants(), grog.Pt(0, 0), grog.Pt(2, 2), 0, nil)
+
+	want := image.NewRGBA(img.Rect)
+	draw.Draw(want, image.Rect(0, 0, 4, 4), image.NewUniform(red), image.ZP, draw.Src)
+	draw.Draw(want, image.Rect(4, 0, 8, 4), image.NewUniform(green), image.ZP, draw.Src)
+	draw.Draw(want, image.Rect(4, 4, 8, 8), image.NewUniform(blue), image.ZP, draw.Src)
+	draw.Draw(want, image.Rect(0, 4, 4, 8), image.NewUniform(yellow), image.ZP, draw.Src)
+	compare(t, img, want)
+}
+
+func TestDraw_tint(t *testing.T) {
+	img, r, v := newTarget(4, 4)
+	r.Camera(v)
+	r.Clear(color.RGBA{0, 0, 0xff, 0xff})
+	// half transparent yellow over opaque blue
+	r.Draw(quadrants().Region(image.Rect(0, 2, 2, 4), image.ZP), grog.Pt(1, 1), grog.Pt(1, 1), 0, color.RGBA{0x80, 0x80, 0x80, 0x80})
+
+	want := image.NewRGBA(img.Rect)
+	draw.Draw(want, want.Rect, image.NewUniform(blue), image.ZP, draw.Src)
+	draw.Draw(want, image.Rect(1, 1, 3, 3), image.NewUniform(color.RGBA{0x80, 0x80, 0x7f, 0xff}), image.ZP, draw.Src)
+	compare(t, img, want)
+
+	// half transparent red, alpha premultiplied, over transparent black
+
+	img, r, v = newTarget(2, 2)
+	r.Camera(v)
+	r.Draw(quadrants().Region(image.Rect(0, 0, 2, 2), image.ZP), grog.Pt(0, 0), grog.Pt(1, 1), 0, color.RGBA{0x80, 0, 0, 0x80})
+	want = image.NewRGBA(img.Rect)
+	draw.Draw(want, want.Rect, image.NewUniform(color.RGBA{0x80, 0, 0, 0x80}), image.ZP, draw.Src)
+	compare(t, img, want)
+}
+
+func TestDraw_region(t *testing.T) {
+	tex := quadrants()
+	img, r, v := newTarget(8, 8)
+	r.Camera(v)
+	// green quadrant, with its point of origin in its center
+	r.Draw(tex.Region(image.Rect(2, 0, 4, 2), image.Pt(1, 1)), grog.Pt(1, 1), grog.Pt(1, 1), 0, nil)
+	// sub-region of a region: bottom right pixel of the blue quadrant. Like
+	// grog.Region.Region, the origin is offset by the parent bounds: (2, 2),
+	// scaled by (3, 2).
+	r.Draw(tex.Region(image.Rect(2, 2, 4, 4), image.ZP).Region(image.Rect(1, 1, 2, 2), image.ZP), grog.Pt(9, 8), grog.Pt(3, 2), 0, nil)
+
+	want := image.NewRGBA(img.Rect)
+	draw.Draw(want, image.Rect(0, 0, 2, 2), image.NewUniform(green), image.ZP, draw.Src)
+	draw.Draw(want, image.Rect(3, 4, 6, 6), image.NewUniform(blue), image.ZP, draw.Src)
+	compare(t, img, want)
+}
+
+func TestRegion_Region(t *testing.T) {
+	sub := soft.NewTexture(8, 8).Region(image.Rect(2, 3, 6, 7), image.Pt(1, 1)).Region(image.Rect(1, 1, 3, 2), image.Pt(1, 0))
+	// same as grog.Region.Region
+	if o := sub.Origin(); o != image.Pt(3, 3) {
+		t.Errorf("Origin() = %v, want (3,3)", o)
+	}
+	if b := sub.Rect(); b != image.Rect(3, 4, 5, 5) {
+		t.Errorf("Rect() = %v, want (3,4)-(5,5)", b)
+	}
+}
+
+func TestDraw_rotated(t *testing.T) {
+	img, r, v := newTarget(4, 4)
+	r.Camera(v)
+	// rotate by 90 degrees clockwise around the center of the texture
+	r.Draw(quadrants().Region(image.Rect(0, 0, 4, 4), image.Pt(2, 2)), grog.Pt(2, 2), grog.Pt(1, 1), 1.5707964, nil)
+
+	want := image.NewRGBA(img.Rect)
+	draw.Draw(want, image.Rect(0, 0, 2, 2), image.NewUniform(yellow), image.ZP, draw.Src)
+	draw.Draw(want, image.Rect(2, 0, 4, 2), image.NewUniform(red), image.ZP, draw.Src)
+	draw.Draw(want, image.Rect(2, 2, 4, 4), image.NewUniform(green), image.ZP, draw.Src)
+	draw.Draw(want, image.Rect(0, 2, 2, 4), image.NewUniform(blue), image.ZP, draw.Src)
+	compare(t, img, want)
+}
+
+func TestDraw_clip(t *testing.T) {
+	img, r, v := newTarget(8, 8)
+	sub := &grog.View{Fb: v.Fb, Rect: image.Rect(2, 2, 6, 6), Scale: 1}
+	r.Camera(sub)
+	r.Clear(red)
+	// view origin at its top left corner, so that (-2, -2) is the top left
+	// pixel of the frame buffer.
+	r.Draw(quadrants(), grog.Pt(-2, -2), grog.Pt(2, 2), 0, nil)
+
+	want := image.NewRGBA(img.Rect)
+	draw.Draw(want, image.Rect(2, 2, 6, 6), image.NewUniform(red), image.ZP, draw.Src)
+	draw.Draw(want, image.Rect(4, 2, 6, 4), image.NewUniform(green), image.ZP, draw.Src)
+	draw.Draw(want, image.Rect(4, 4, 6, 6), image.NewUniform(blue), image.ZP, draw.Src)
+	draw.Draw(want, image.Rect(2, 4, 4, 6), image.NewUniform(yellow), image.ZP, draw.Src)
+	compare(t, img, want)
+}
+
+func TestDrawString(t *testing.T) {
+	const s = "Hello, grog!"
+	face := basicfont.Face7x13
+	img, r, v := newTarget(100, 20)
+	r.Camera(v)
+	td := grog.NewTextDrawerFunc(face, soft.NewGlyphTexture)
+	td.DrawString(r, s, grog.Pt(2, 14), grog.Pt(1, 1), nil)
+
+	want := image.NewRGBA(img.Rect)
+	fd := font.Drawer{Dst: want, Src: image.White, Face: face, Dot: fixed.P(2, 14)}
+	fd.DrawString(s)
+	compare(t, img, want)
+
+	// tinted, from the glyph cache
+	img, r, _ = newTarget(100, 20)
+	r.Camera(v)
+	td.DrawString(r, s, grog.Pt(2, 14), grog.Pt(1, 1), red)
+	want = image.NewRGBA(img.Rect)
+	fd = font.Drawer{Dst: want, Src: image.NewUniform(red), Face: face, Dot: fixed.P(2, 14)}
+	fd.DrawString(s)
+	compare(t, img, want)
+}
+
+func TestGlyph(t *testing.T) {
+	td := grog.NewTextDrawerFunc(basicfont.Face7x13, soft.NewGlyphTexture)
+	dp, g, adv := td.GlyphDrawable(fixed.P(2, 14), 'A')
+	if g == nil || g.Size().X == 0 || g.Size().Y == 0 {
+		t.Fatalf("GlyphDrawable: got %v", g)
+	}
+	// soft glyph textures are not grog textures: no Region
+	dp2, r, adv2 := td.Glyph(fixed.P(2, 14), 'A')
+	if r != nil {
+		t.Errorf("Glyph: got region %v, want nil", r)
+	}
+	if dp2 != dp || adv2 != adv {
+		t.Errorf("Glyph: got %v %v, want %v %v", dp2, adv2, dp, adv)
+	}
+}
+
+// glTexture is a Drawable with the NativeID of an OpenGL texture.
+//
+type glTexture struct{ grog.Drawable }
+
+func (glTexture) NativeID() uint32 { return 1 }
+
+func TestDraw_glTexture(t *testing.T) {
+	_, r, v := newTarget(4, 4)
+	r.Camera(v)
+	defer func() {
+		if recover() == nil {
+			t.Error("drawing an OpenGL texture did not panic")
+		}
+	}()
+	r.Draw(glTexture{quadrants()}, grog.Pt(0, 0), grog.Pt(1, 1), 0, nil)
+}
+
+func TestDraw_deleted(t *testing.T) {
+	img, r, v := newTarget(4, 4)
+	r.Camera(v)
+	tex := quadrants()
+	tex.Delete()
+	r.Draw(tex, grog.Pt(0, 0), grog.Pt(1, 1), 0, nil)
+	compare(t, img, image.NewRGBA(img.Rect))
+}
+
+func TestFillWrapped(t *testing.T) {
+	img, r, v := newTarget(8, 4)
+	r.Camera(v)
+	// red and green top half of the texture, tiled across 8x4 pixels
+	grog.FillWrapped(r, quadrants().Region(image.Rect(0, 0, 4, 2), image.ZP), grog.Pt(0, 0), grog.Pt(8, 4), grog.Pt(0, 0), grog.Pt(1, 1), nil)
+
+	want := image.NewRGBA(img.Rect)
+	for _, x := range []int{0, 4} {
+		draw.Draw(want, image.Rect(x, 0, x+2, 4), image.NewUniform(red), image.ZP, draw.Src)
+		draw.Draw(want, image.Rect(x+2, 0, x+4, 4), image.NewUniform(green), image.ZP, draw.Src)
+	}
+	compare(t, img, want)
+}
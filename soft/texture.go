@@ -0,0 +1,202 @@
+package soft
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"sync"
+
+	"github.com/db47h/grog"
+)
+
+// firstID is the ID of the first soft texture. It is far above the names
+// allocated by OpenGL implementations, so that OpenGL textures are detected.
+//
+const firstID = 1 << 31
+
+// Texture registry. Renderers look up textures by NativeID, just like OpenGL
+// does.
+//
+var (
+	texMu    sync.RWMutex
+	textures = make(map[uint32]*Texture)
+	nextID   = uint32(firstID - 1)
+)
+
+// white is the texture used by DrawTriangles when no Drawable is given.
+//
+var white = newTexture(&image.RGBA{Pix: []uint8{0xff, 0xff, 0xff, 0xff}, Stride: 4, Rect: image.Rect(0, 0, 1, 1)})
+
+// lookupTexture returns the texture with the given ID, or nil if it has been
+// deleted. It panics if id is not the ID of a soft texture, like the NativeID
+// of an OpenGL texture.
+//
+func lookupTexture(id uint32) *Texture {
+	if id < firstID {
+		panic(fmt.Sprintf("soft: texture ID %d is not a soft texture", id))
+	}
+	texMu.RLock()
+	t := textures[id]
+	texMu.RUnlock()
+	return t
+}
+
+// A Texture is a Drawable that represents a texture in main memory.
+//
+type Texture struct {
+	img       *image.RGBA
+	glID      uint32
+	minFilter grog.TextureFilter
+	magFilter grog.TextureFilter
+}
+
+// NewTexture returns a new transparent texture of the given width and height.
+//
+func NewTexture(width, height int) *Texture {
+	return newTexture(image.NewRGBA(image.Rect(0, 0, width, height)))
+}
+
+// NewGlyphTexture returns a new texture of the given width and height as a
+// grog.GlyphTexture. It is meant to be used with grog.NewTextDrawerFunc:
+//
+//	td := grog.NewTextDrawerFunc(face, soft.NewGlyphTexture)
+//
+func NewGlyphTexture(width, height int) grog.GlyphTexture {
+	t := NewTexture(width, height)
+	t.SetFilter(grog.Linear, grog.Nearest)
+	return t
+}
+
+// TextureFromImage creates a new texture of the same dimensions as the source image.
+//
+func TextureFromImage(src image.Image) *Texture {
+	sr := src.Bounds()
+	dst := image.NewRGBA(image.Rectangle{Max: sr.Size()})
+	draw.Draw(dst, dst.Rect, src, sr.Min, draw.Src)
+	return newTexture(dst)
+}
+
+func newTexture(img *image.RGBA) *Texture {
+	t := &Texture{img: img, minFilter: grog.Nearest, magFilter: grog.Nearest}
+	texMu.Lock()
+	nextID++
+	t.glID = nextID
+	textures[t.glID] = t
+	texMu.Unlock()
+	return t
+}
+
+// SetFilter sets the texture filters used when minifying or magnifying. The
+// default is Nearest for both.
+//
+func (t *Texture) SetFilter(min, mag grog.TextureFilter) {
+	t.minFilter = min
+	t.magFilter = mag
+}
+
+// Image returns the texture's pixel data.
+//
+func (t *Texture) Image() *image.RGBA {
+	return t.img
+}
+
+// SetSubImage draws src to the texture. It works identically to draw.Draw with op set to draw.Src.
+//
+func (t *Texture) SetSubImage(dr image.Rectangle, src image.Image, sp image.Point) {
+	draw.Draw(t.img, dr, src, sp, draw.Src)
+}
+
+// Bind is a no-op.
+//
+func (t *Texture) Bind() {}
+
+// NativeID returns the identifier of the texture in the texture registry.
+//
+func (t *Texture) NativeID() uint32 {
+	return t.glID
+}
+
+// Origin returns the point of origin of the texture.
+//
+func (t *Texture) Origin() image.Point {
+	return image.ZP
+}
+
+// Size returns the size of the texture.
+//
+func (t *Texture) Size() image.Point {
+	return t.img.Rect.Size()
+}
+
+// UV returns the texture's UV coordinates in the range [0, 1]
+//
+func (t *Texture) UV() [4]float32 {
+	return [4]float32{0, 1, 1, 0}
+}
+
+// Delete removes the texture from the texture registry. Drawing a deleted
+// texture draws nothing.
+//
+func (t *Texture) Delete() {
+	texMu.Lock()
+	delete(textures, t.glID)
+	texMu.Unlock()
+}
+
+// Region returns a region within the texture.
+//
+func (t *Texture) Region(bounds image.Rectangle, origin image.Point) *Region {
+	return &Region{
+		Texture: t,
+		origin:  origin,
+		bounds:  bounds,
+	}
+}
+
+// Region is a Drawable that represents a sub-region in a Texture or
+// another Region.
+//
+type Region struct {
+	*Texture
+	origin image.Point
+	bounds image.Rectangle
+}
+
+// Origin returns the point of origin of the region.
+//
+func (r *Region) Origin() image.Point {
+	return r.origin
+}
+
+// Rect returns the region's bounding rectangle within the parent texture.
+//
+func (r *Region) Rect() image.Rectangle {
+	return r.bounds
+}
+
+// Size returns the size of the region.
+//
+func (r *Region) Size() image.Point {
+	return r.bounds.Size()
+}
+
+// UV returns the regions's UV coordinates in the range [0, 1]
+//
+func (r *Region) UV() [4]float32 {
+	sz := r.Texture.Size()
+	w, h := float32(sz.X), float32(sz.Y)
+	u0, v0 := float32(r.bounds.Min.X)/w, float32(r.bounds.Min.Y)/h
+	u1, v1 := float32(r.bounds.Max.X)/w, float32(r.bounds.Max.Y)/h
+	return [4]float32{u0, v1, u1, v0}
+}
+
+// Region returns a sub-region within the Region. Like grog.Region.Region,
+// bounds and origin are relative to the top left corner of r.
+//
+func (r *Region) Region(bounds image.Rectangle, origin image.Point) *Region {
+	return &Region{
+		Texture: r.Texture,
+		origin:  origin.Add(r.bounds.Min),
+		bounds:  bounds.Add(r.bounds.Min),
+	}
+}
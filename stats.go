@@ -0,0 +1,48 @@
+package grog
+
+// Stats holds the rendering statistics of a batch. See StatsRenderer.
+//
+type Stats struct {
+	DrawCalls int // number of draw calls issued to OpenGL
+	Quads     int // number of quads drawn, including quads used by triangles and meshes
+	Uploaded  int // number of bytes of vertex and index data uploaded to OpenGL
+	Culled    int // number of quads culled, see CullingRenderer
+
+	// Flushes by cause. A flush that sends no vertices is not counted.
+	FullFlushes    int // the batch buffer was full
+	TextureFlushes int // all texture units were in use
+	CameraFlushes  int // Camera or Clear
+	StateFlushes   int // material, uniform or blend mode change
+	EndFlushes     int // Flush or End
+}
+
+type flushReason int
+
+const (
+	flushFull flushReason = iota
+	flushTexture
+	flushCamera
+	flushState
+	flushEnd
+)
+
+// flush counts a flush of n quads.
+//
+func (s *Stats) flush(r flushReason, n int) {
+	if n == 0 {
+		return
+	}
+	s.Quads += n
+	switch r {
+	case flushFull:
+		s.FullFlushes++
+	case flushTexture:
+		s.TextureFlushes++
+	case flushCamera:
+		s.CameraFlushes++
+	case flushState:
+		s.StateFlushes++
+	case flushEnd:
+		s.EndFlushes++
+	}
+}
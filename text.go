@@ -53,12 +53,42 @@ var FontTextureSize int = 1024
 //
 type TextDrawer struct {
 	face   font.Face
-	glyphs []Region
+	glyphs []glyph
 	cache  map[cacheKey]cacheValue
-	ts     []*Texture  // current texture
-	p      image.Point // current point
-	lh     int         // line height in current texture
-	mf     TextureFilter
+	ts     []GlyphTexture // current texture
+	p      image.Point    // current point
+	lh     int            // line height in current texture
+	newTex func(width, height int) GlyphTexture
+}
+
+// GlyphTexture is implemented by textures that a TextDrawer can use to cache
+// glyphs. *Texture implements GlyphTexture.
+//
+type GlyphTexture interface {
+	Drawable
+	SetSubImage(dr image.Rectangle, src image.Image, sp image.Point)
+}
+
+// glyph is a Drawable for a glyph in a GlyphTexture. It works like a Region.
+// If the GlyphTexture is a *Texture, r is the Region of the glyph, otherwise
+// only its origin and bounds are set.
+//
+type glyph struct {
+	t GlyphTexture
+	r Region
+}
+
+func (g *glyph) Bind()               { g.t.Bind() }
+func (g *glyph) NativeID() uint32    { return g.t.NativeID() }
+func (g *glyph) Origin() image.Point { return g.r.origin }
+func (g *glyph) Size() image.Point   { return g.r.bounds.Size() }
+
+func (g *glyph) UV() [4]float32 {
+	sz := g.t.Size()
+	w, h := float32(sz.X), float32(sz.Y)
+	u0, v0 := float32(g.r.bounds.Min.X)/w, float32(g.r.bounds.Min.Y)/h
+	u1, v1 := float32(g.r.bounds.Max.X)/w, float32(g.r.bounds.Max.Y)/h
+	return [4]float32{u0, v1, u1, v0}
 }
 
 type cacheKey struct {
@@ -90,10 +120,22 @@ const (
 // the texture filter used when up-scaling.
 //
 func NewTextDrawer(f font.Face, magFilter TextureFilter) *TextDrawer {
+	return NewTextDrawerFunc(f, func(width, height int) GlyphTexture {
+		return TextureFromImage(image.NewRGBA(image.Rect(0, 0, width, height)), Filter(Linear, magFilter))
+	})
+}
+
+// NewTextDrawerFunc returns a new TextDrawer using the given font face. Glyph
+// cache textures of size FontTextureSize x FontTextureSize are allocated by
+// calling newTexture. The returned textures must be fully transparent.
+//
+// This is how text can be drawn with renderers that do not use OpenGL textures.
+//
+func NewTextDrawerFunc(f font.Face, newTexture func(width, height int) GlyphTexture) *TextDrawer {
 	return &TextDrawer{
-		face:  f,
-		cache: make(map[cacheKey]cacheValue),
-		mf:    magFilter,
+		face:   f,
+		cache:  make(map[cacheKey]cacheValue),
+		newTex: newTexture,
 	}
 }
 
@@ -115,7 +157,7 @@ func (d *TextDrawer) DrawBytes(batch Renderer, s []byte, dp, scale Point, c colo
 		if prev >= 0 {
 			dot.X += d.face.Kern(prev, r)
 		}
-		gp, glyph, advance := d.Glyph(dot, r)
+		gp, glyph, advance := d.glyph(dot, r)
 		if glyph != nil {
 			batch.Draw(glyph, PtPt(gp), scale, 0, c)
 		}
@@ -135,7 +177,7 @@ func (d *TextDrawer) DrawString(batch Renderer, s string, dp, scale Point, c col
 		if prev >= 0 {
 			dot.X += d.face.Kern(prev, r)
 		}
-		gp, glyph, advance := d.Glyph(dot, r)
+		gp, glyph, advance := d.glyph(dot, r)
 		if glyph != nil {
 			batch.Draw(glyph, PtPt(gp), scale, 0, c)
 		}
@@ -145,7 +187,7 @@ func (d *TextDrawer) DrawString(batch Renderer, s string, dp, scale Point, c col
 	return float32(dot.X-sp) / 64
 }
 
-func (d *TextDrawer) currentTexture() *Texture {
+func (d *TextDrawer) currentTexture() GlyphTexture {
 	l := len(d.ts)
 	if l == 0 {
 		return nil
@@ -156,7 +198,30 @@ func (d *TextDrawer) currentTexture() *Texture {
 // Glyph returns the glyph texture Region for rune r drawn at dot, the draw
 // point (for batch.Draw) as well as the advance.
 //
+// The returned Region is nil if the glyph textures of d are not *Texture, as
+// with a TextDrawer created by NewTextDrawerFunc with another GlyphTexture
+// type. Use GlyphDrawable in that case.
+//
 func (d *TextDrawer) Glyph(dot fixed.Point26_6, r rune) (dp image.Point, gr *Region, advance fixed.Int26_6) {
+	dp, g, advance := d.glyph(dot, r)
+	if g == nil || g.r.Texture == nil {
+		return dp, nil, advance
+	}
+	return dp, &g.r, advance
+}
+
+// GlyphDrawable is like Glyph, but returns the glyph as a Drawable, whatever
+// the type of the glyph textures of d.
+//
+func (d *TextDrawer) GlyphDrawable(dot fixed.Point26_6, r rune) (dp image.Point, gr Drawable, advance fixed.Int26_6) {
+	dp, g, advance := d.glyph(dot, r)
+	if g == nil {
+		return dp, nil, advance
+	}
+	return dp, g, advance
+}
+
+func (d *TextDrawer) glyph(dot fixed.Point26_6, r rune) (dp image.Point, gr *glyph, advance fixed.Int26_6) {
 	dx, dy := (dot.X+fontSubPixelBiasX)&fontSubPixelMaskX, (dot.Y+fontSubPixelBiasY)&fontSubPixelMaskY
 	ix, iy := int(dx>>6), int(dy>>6)
 
@@ -193,8 +258,7 @@ func (d *TextDrawer) Glyph(dot fixed.Point26_6, r rune) (dp image.Point, gr *Reg
 		}
 	}
 	if t == nil {
-		t = TextureFromImage(image.NewRGBA(image.Rect(0, 0, FontTextureSize, FontTextureSize)),
-			Filter(Linear, d.mf))
+		t = d.newTex(FontTextureSize, FontTextureSize)
 		d.ts = append(d.ts, t)
 		d.p = image.Point{1, 1}
 		tr = dr.Add(image.Pt(-dr.Min.X+d.p.X, -dr.Min.Y+d.p.Y))
@@ -206,7 +270,11 @@ func (d *TextDrawer) Glyph(dot fixed.Point26_6, r rune) (dp image.Point, gr *Reg
 		d.lh = h
 	}
 	index := int32(len(d.glyphs))
-	d.glyphs = append(d.glyphs, *t.Region(tr, org))
+	g := glyph{t: t, r: Region{origin: org, bounds: tr}}
+	if tex, ok := t.(*Texture); ok {
+		g.r.Texture = tex
+	}
+	d.glyphs = append(d.glyphs, g)
 	d.cache[key] = cacheValue{index, advance}
 	return image.Point{X: ix, Y: iy}, &d.glyphs[index], advance
 }
@@ -22,7 +22,8 @@ const (
 // the range [0, 1].
 //
 // When used in conjunction with github.com/db47h/grog/batch, the only settings
-// that make sense are ClampToEdge (the default) and ClampToBorder.
+// that make sense are ClampToEdge (the default) and ClampToBorder. To repeat
+// regions of a texture, use FillTiled or FillWrapped.
 //
 type TextureWrap int32
 
@@ -40,6 +41,7 @@ type Texture struct {
 	width  int
 	height int
 	glID   uint32
+	flipY  bool // the first row of pixels is at the bottom, as in frame buffers
 }
 
 type tp struct {
@@ -186,9 +188,13 @@ func (t *Texture) SetSubImage(dr image.Rectangle, src image.Image, sp image.Poin
 // GLCoords return the coordinates of the point pt mapped to the range [0, 1].
 //
 func (t *Texture) GLCoords(pt Point) Point {
+	y := float32(pt.Y) / float32(t.height)
+	if t.flipY {
+		y = 1 - y
+	}
 	return Point{
 		X: float32(pt.X) / float32(t.width),
-		Y: float32(pt.Y) / float32(t.height),
+		Y: y,
 	}
 }
 
@@ -207,6 +213,9 @@ func (t *Texture) Size() image.Point {
 // UV returns the texture's UV coordinates in the range [0, 1]
 //
 func (t *Texture) UV() [4]float32 {
+	if t.flipY {
+		return [4]float32{0, 0, 1, 1}
+	}
 	return [4]float32{0, 1, 1, 0}
 }
 
@@ -265,6 +274,9 @@ func (r *Region) UV() [4]float32 {
 	w, h := float32(r.width), float32(r.height)
 	u0, v0 := float32(r.bounds.Min.X)/w, float32(r.bounds.Min.Y)/h
 	u1, v1 := float32(r.bounds.Max.X)/w, float32(r.bounds.Max.Y)/h
+	if r.flipY {
+		return [4]float32{u0, 1 - v1, u1, 1 - v0}
+	}
 	return [4]float32{u0, v1, u1, v0}
 }
 
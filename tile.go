@@ -0,0 +1,135 @@
+package grog
+
+import (
+	"image/color"
+	"math"
+)
+
+// FillTiled fills the rectangle with corners p0 and p1 with copies of d, drawn
+// at its size times scale. Tiles are aligned on p0-offset: changing offset
+// scrolls the tiles within the rectangle, for parallax backgrounds for
+// example. A negative scale mirrors the tiles along its axis. The origin of d
+// is ignored.
+//
+// FillTiled works with any Drawable, including regions of a texture atlas: one
+// quad is drawn per tile, and tiles on the edges of the rectangle are cut by
+// adjusting their UV coordinates. See FillWrapped for a shader based
+// alternative that draws a single quad.
+//
+func FillTiled(r MeshRenderer, d Drawable, p0, p1, offset, scale Point, c color.Color) {
+	xs, ys, ok := tileGrid(d, p0, p1, offset, scale)
+	if !ok {
+		return
+	}
+	var (
+		uv = d.UV()
+		gc = vertexColor(c)
+		q  [4]Vertex
+	)
+	if scale.X < 0 {
+		uv[0], uv[2] = uv[2], uv[0]
+	}
+	if scale.Y < 0 {
+		uv[1], uv[3] = uv[3], uv[1]
+	}
+	for j := 0; j < ys.count; j++ {
+		y := ys.first + float32(j)*ys.size
+		y0, y1 := maxf(y, p0.Y), minf(y+ys.size, p1.Y)
+		v0 := uv[3] + (y0-y)/ys.size*(uv[1]-uv[3])
+		v1 := uv[3] + (y1-y)/ys.size*(uv[1]-uv[3])
+		for i := 0; i < xs.count; i++ {
+			x := xs.first + float32(i)*xs.size
+			x0, x1 := maxf(x, p0.X), minf(x+xs.size, p1.X)
+			u0 := uv[0] + (x0-x)/xs.size*(uv[2]-uv[0])
+			u1 := uv[0] + (x1-x)/xs.size*(uv[2]-uv[0])
+			q = [4]Vertex{
+				{Pos: Point{x0, y0}, UV: Point{u0, v0}, Color: gc},
+				{Pos: Point{x1, y0}, UV: Point{u1, v0}, Color: gc},
+				{Pos: Point{x1, y1}, UV: Point{u1, v1}, Color: gc},
+				{Pos: Point{x0, y1}, UV: Point{u0, v1}, Color: gc},
+			}
+			r.DrawQuad(d, &q)
+		}
+	}
+}
+
+// FillWrapped is like FillTiled, but draws a single quad whose texture
+// coordinates wrap around within the region of d in the fragment shader: the
+// region is passed in the Wrap field of vertices. Wrapped fills batch like any
+// other quad.
+//
+// The default material and the soft package support wrapping. Custom
+// materials must implement it in their shaders (see Material). Since texture
+// coordinates are discontinuous at the edges of tiles, the texture of d should
+// not use mipmaps.
+//
+func FillWrapped(r MeshRenderer, d Drawable, p0, p1, offset, scale Point, c color.Color) {
+	xs, ys, ok := tileGrid(d, p0, p1, offset, scale)
+	if !ok {
+		return
+	}
+	var (
+		wr     = d.UV()
+		gc     = vertexColor(c)
+		u0, v0 = (p0.X - xs.first) / xs.size, (p0.Y - ys.first) / ys.size
+		u1, v1 = (p1.X - xs.first) / xs.size, (p1.Y - ys.first) / ys.size
+	)
+	// fract(-u) = 1 - fract(u): negative coordinates mirror the tiles
+	if scale.X < 0 {
+		u0, u1 = -u0, -u1
+	}
+	if scale.Y < 0 {
+		v0, v1 = -v0, -v1
+	}
+	q := [4]Vertex{
+		{Pos: p0, UV: Point{u0, v0}, Color: gc, Wrap: wr},
+		{Pos: Point{p1.X, p0.Y}, UV: Point{u1, v0}, Color: gc, Wrap: wr},
+		{Pos: p1, UV: Point{u1, v1}, Color: gc, Wrap: wr},
+		{Pos: Point{p0.X, p1.Y}, UV: Point{u0, v1}, Color: gc, Wrap: wr},
+	}
+	r.DrawQuad(d, &q)
+}
+
+// A tileAxis is the layout of tiles along one axis: the position of the first
+// tile overlapping the filled area, the size of tiles and the number of tiles
+// overlapping the area.
+//
+type tileAxis struct {
+	first, size float32
+	count       int
+}
+
+// tileGrid returns the layout of tiles along the x and y axes. ok is false if
+// there is nothing to draw.
+//
+func tileGrid(d Drawable, p0, p1, offset, scale Point) (xs, ys tileAxis, ok bool) {
+	sz := d.Size()
+	w, h := float32(sz.X)*absf(scale.X), float32(sz.Y)*absf(scale.Y)
+	if w == 0 || h == 0 || p1.X <= p0.X || p1.Y <= p0.Y {
+		return xs, ys, false
+	}
+	return newTileAxis(p0.X, p1.X, offset.X, w), newTileAxis(p0.Y, p1.Y, offset.Y, h), true
+}
+
+func newTileAxis(p0, p1, offset, size float32) tileAxis {
+	first := p0 - (offset - floorf(offset/size)*size)
+	if first > p0 {
+		first -= size
+	}
+	return tileAxis{
+		first: first,
+		size:  size,
+		count: int(math.Ceil(float64((p1 - first) / size))),
+	}
+}
+
+func absf(x float32) float32 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func floorf(x float32) float32 {
+	return float32(math.Floor(float64(x)))
+}
@@ -0,0 +1,81 @@
+// +build egl
+
+package grog
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestFillWrapped_pixels(t *testing.T) {
+	defer glContext(t)()
+	var (
+		red    = color.RGBA{255, 0, 0, 255}
+		green  = color.RGBA{0, 255, 0, 255}
+		blue   = color.RGBA{0, 0, 255, 255}
+		screen = NewScreen(image.Pt(glWidth, glHeight))
+		img    = image.NewRGBA(image.Rect(0, 0, 8, 4))
+	)
+	// atlas: a red and green striped region next to a blue one
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 8; x++ {
+			c := blue
+			if x < 2 {
+				c = red
+			} else if x < 4 {
+				c = green
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	tex := TextureFromImage(img, Filter(Nearest, Nearest))
+	defer tex.Delete()
+	stripes, solid := tex.Region(image.Rect(0, 0, 4, 4), image.ZP), tex.Region(image.Rect(4, 0, 8, 4), image.ZP)
+
+	for _, bt := range []struct {
+		name string
+		opts []BatchOption
+	}{
+		{"sync", nil},
+		{"concurrent", []BatchOption{Concurrent(true)}},
+	} {
+		b, err := NewBatch(bt.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.Begin()
+		b.Camera(screen.View())
+		b.Clear(color.Transparent)
+		FillWrapped(b.(MeshRenderer), stripes, Pt(0, 0), Pt(16, 4), Pt(0, 0), Pt(1, 1), nil)
+		FillWrapped(b.(MeshRenderer), stripes, Pt(0, 4), Pt(16, 8), Pt(1, 0), Pt(1, 1), nil)
+		b.Draw(solid, Pt(20, 0), Pt(1, 1), 0, nil)
+		b.End()
+
+		if s := b.(StatsRenderer).Stats(); s.DrawCalls != 1 {
+			t.Errorf("%s: %d draw calls, want 1", bt.name, s.DrawCalls)
+		}
+		got := screen.Capture(image.Rect(0, 0, 24, 8))
+		for x := 0; x < 16; x++ {
+			want := red
+			if x%4 >= 2 {
+				want = green
+			}
+			if c := got.RGBAAt(x, 1); c != want {
+				t.Errorf("%s: pixel (%d, 1) = %v, want %v", bt.name, x, c, want)
+			}
+			// offset by one pixel
+			want = red
+			if (x+1)%4 >= 2 {
+				want = green
+			}
+			if c := got.RGBAAt(x, 5); c != want {
+				t.Errorf("%s: pixel (%d, 5) = %v, want %v", bt.name, x, c, want)
+			}
+		}
+		if c := got.RGBAAt(21, 1); c != blue {
+			t.Errorf("%s: sprite pixel = %v, want %v", bt.name, c, blue)
+		}
+		b.Close()
+	}
+}
@@ -0,0 +1,119 @@
+package grog
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+func TestNewTileAxis(t *testing.T) {
+	for _, tc := range []struct {
+		p0, p1, offset, size float32
+		want                 tileAxis
+	}{
+		{0, 100, 0, 32, tileAxis{0, 32, 4}},
+		{0, 64, 0, 32, tileAxis{0, 32, 2}},
+		{0, 100, 10, 32, tileAxis{-10, 32, 4}},
+		{0, 100, -10, 32, tileAxis{-22, 32, 4}},
+		{0, 100, 32, 32, tileAxis{0, 32, 4}},
+		{0, 100, 69, 32, tileAxis{-5, 32, 4}},
+		{5, 100, 0, 32, tileAxis{5, 32, 3}},
+		{-50, -40, 3, 16, tileAxis{-53, 16, 1}},
+		{0, 10, 0, 16, tileAxis{0, 16, 1}},
+		{0, 1.5, 0.5, 0.5, tileAxis{0, 0.5, 3}},
+	} {
+		got := newTileAxis(tc.p0, tc.p1, tc.offset, tc.size)
+		if got != tc.want {
+			t.Errorf("newTileAxis(%g, %g, %g, %g) = %+v, want %+v", tc.p0, tc.p1, tc.offset, tc.size, got, tc.want)
+		}
+		if got.first > tc.p0 || got.first+got.size <= tc.p0 {
+			t.Errorf("newTileAxis(%g, %g, %g, %g): first tile does not overlap p0", tc.p0, tc.p1, tc.offset, tc.size)
+		}
+		if last := got.first + float32(got.count)*got.size; last < tc.p1 || last-got.size >= tc.p1 {
+			t.Errorf("newTileAxis(%g, %g, %g, %g): last tile ends at %g", tc.p0, tc.p1, tc.offset, tc.size, last)
+		}
+	}
+	// offsets close to a multiple of size
+	for _, offset := range []float32{-1e-5, 1e-5, 32 - 1e-5, -32 + 1e-5} {
+		got := newTileAxis(0, 64, offset, 32)
+		if got.first > 0 || got.first+got.size <= 0 {
+			t.Errorf("offset %g: first tile at %g does not overlap 0", offset, got.first)
+		}
+	}
+}
+
+// A quadLog is a MeshRenderer that records quads.
+//
+type quadLog struct {
+	basicRecorder
+	quads [][4]Vertex
+}
+
+func (l *quadLog) SetBlendMode(BlendMode)                            {}
+func (l *quadLog) Begin()                                            {}
+func (l *quadLog) Flush()                                            {}
+func (l *quadLog) End()                                              {}
+func (l *quadLog) Close()                                            {}
+func (l *quadLog) DrawTriangles(d Drawable, v []Vertex)              {}
+func (l *quadLog) DrawQuad(d Drawable, q *[4]Vertex)                 { l.quads = append(l.quads, *q) }
+func (l *quadLog) DrawMesh(d Drawable, v []Vertex, indices []uint16) {}
+
+func checkQuads(t *testing.T, name string, got [][4]Vertex, want [][4]Point) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s: got %d quads, want %d", name, len(got), len(want))
+	}
+	for i := range got {
+		for j := range got[i] {
+			if uv := got[i][j].UV; math.Abs(float64(uv.X-want[i][j].X)) > 1e-5 || math.Abs(float64(uv.Y-want[i][j].Y)) > 1e-5 {
+				t.Errorf("%s: quad %d vertex %d UV = %v, want %v", name, i, j, uv, want[i][j])
+			}
+		}
+	}
+}
+
+func TestFillTiled_mirror(t *testing.T) {
+	d := &testDrawable{1, image.Pt(16, 16)} // UV: left 0, bottom 1, right 1, top 0
+	for _, tc := range []struct {
+		name  string
+		p1    Point
+		scale Point
+		want  [][4]Point
+	}{
+		{"normal", Pt(16, 16), Pt(1, 1), [][4]Point{{{0, 0}, {1, 0}, {1, 1}, {0, 1}}}},
+		{"mirror x", Pt(16, 16), Pt(-1, 1), [][4]Point{{{1, 0}, {0, 0}, {0, 1}, {1, 1}}}},
+		{"mirror y", Pt(16, 16), Pt(1, -1), [][4]Point{{{0, 1}, {1, 1}, {1, 0}, {0, 0}}}},
+		{"mirror x, cut", Pt(24, 8), Pt(-1, 1), [][4]Point{
+			{{1, 0}, {0, 0}, {0, .5}, {1, .5}},
+			{{1, 0}, {.5, 0}, {.5, .5}, {1, .5}},
+		}},
+	} {
+		var l quadLog
+		FillTiled(&l, d, Pt(0, 0), tc.p1, Pt(0, 0), tc.scale, nil)
+		checkQuads(t, tc.name, l.quads, tc.want)
+	}
+}
+
+func TestFillWrapped(t *testing.T) {
+	d := &testDrawable{1, image.Pt(16, 16)}
+
+	var l quadLog
+	FillWrapped(&l, d, Pt(0, 0), Pt(32, 8), Pt(0, 0), Pt(1, 1), nil)
+	checkQuads(t, "normal", l.quads, [][4]Point{{{0, 0}, {2, 0}, {2, .5}, {0, .5}}})
+	for i, v := range l.quads[0] {
+		if v.Wrap != d.UV() {
+			t.Errorf("vertex %d: Wrap = %v, want %v", i, v.Wrap, d.UV())
+		}
+	}
+
+	l = quadLog{}
+	FillWrapped(&l, d, Pt(0, 0), Pt(32, 8), Pt(0, 0), Pt(-1, -1), nil)
+	checkQuads(t, "mirror", l.quads, [][4]Point{{{0, 0}, {-2, 0}, {-2, -.5}, {0, -.5}}})
+
+	// FillTiled does not wrap
+	l = quadLog{}
+	FillTiled(&l, d, Pt(0, 0), Pt(16, 16), Pt(0, 0), Pt(1, 1), nil)
+	if w := l.quads[0][0].Wrap; w != [4]float32{} {
+		t.Errorf("FillTiled: Wrap = %v, want zero", w)
+	}
+}
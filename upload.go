@@ -0,0 +1,151 @@
+package grog
+
+import (
+	"unsafe"
+
+	"github.com/db47h/grog/gl"
+)
+
+// An UploadStrategy selects how batches upload vertices to their vertex
+// buffer.
+//
+type UploadStrategy int
+
+// Supported upload strategies.
+//
+const (
+	// UploadRing appends vertices to the vertex buffer at increasing offsets,
+	// and orphans the buffer when it is full. The driver never has to
+	// overwrite vertices in use by pending draw calls, so it does not need to
+	// wait for them to complete. This is the default.
+	UploadRing UploadStrategy = iota
+	// UploadSubData uploads vertices at the start of the vertex buffer for
+	// every draw call. Depending on the driver, this may stall until the
+	// previous draw call has completed.
+	UploadSubData
+	// UploadPersistent writes vertices directly to a persistently mapped,
+	// coherent vertex buffer split in regions, with fences to prevent
+	// overwriting a region in use by the GPU. It requires OpenGL 4.4 and
+	// extension functions loaded with gl.InitExtC or gl.InitExtGo.
+	UploadPersistent
+)
+
+func (s UploadStrategy) String() string {
+	switch s {
+	case UploadRing:
+		return "ring"
+	case UploadSubData:
+		return "subdata"
+	case UploadPersistent:
+		return "persistent"
+	}
+	return "unknown"
+}
+
+// streamRegions is the number of regions of persistently mapped buffers. One
+// is being written to while the GPU may still read from the others.
+//
+const streamRegions = 3
+
+// A vertexStream keeps track of where vertices are written in a vertex buffer.
+// The buffer is divided in slots of a fixed size: quads for regular batches,
+// sprite records for the instanced batch.
+//
+type vertexStream struct {
+	strategy UploadStrategy
+	stride   int    // size of a slot in bytes
+	slots    int    // capacity of the buffer, or of a region, in slots
+	head     int    // next free slot in the buffer or current region
+	region   int    // current region of a persistently mapped buffer
+	mem      []byte // persistently mapped buffer
+	fences   [streamRegions]gl.Sync
+}
+
+// newStreamBuffer creates a vertex buffer with room for the given number of
+// slots of stride bytes, and a stream to write to it using strategy s, or
+// UploadRing if s is not supported. The new buffer is left bound.
+//
+func newStreamBuffer(s UploadStrategy, stride, slots int) (uint32, vertexStream) {
+	if s == UploadPersistent && !gl.HasBufferStorage() {
+		s = UploadRing
+	}
+	var vbo uint32
+	gl.GenBuffers(1, &vbo)
+	gl.BindBuffer(gl.GL_ARRAY_BUFFER, vbo)
+	vs := vertexStream{strategy: s, stride: stride, slots: slots}
+	switch s {
+	case UploadSubData:
+		gl.BufferData(gl.GL_ARRAY_BUFFER, slots*stride, nil, gl.GL_DYNAMIC_DRAW)
+	case UploadPersistent:
+		const flags = gl.GL_MAP_WRITE_BIT | gl.GL_MAP_PERSISTENT_BIT | gl.GL_MAP_COHERENT_BIT
+		size := streamRegions * slots * stride
+		gl.BufferStorage(gl.GL_ARRAY_BUFFER, size, nil, flags)
+		p := gl.MapBufferRange(gl.GL_ARRAY_BUFFER, 0, size, flags)
+		if p == nil {
+			// buffer storage is immutable: start over with a new buffer.
+			gl.DeleteBuffers(1, &vbo)
+			return newStreamBuffer(UploadRing, stride, slots)
+		}
+		vs.mem = (*[1 << 30]byte)(p)[:size:size]
+	default:
+		vs.strategy = UploadRing
+		gl.BufferData(gl.GL_ARRAY_BUFFER, slots*stride, nil, gl.GL_STREAM_DRAW)
+	}
+	return vbo, vs
+}
+
+// write copies size bytes at p, filling n slots, to the vertex buffer, which
+// must be bound, and returns the slot of the first byte.
+//
+func (s *vertexStream) write(p unsafe.Pointer, n, size int) int {
+	switch s.strategy {
+	case UploadSubData:
+		gl.BufferSubData(gl.GL_ARRAY_BUFFER, 0, size, p)
+		return 0
+	case UploadPersistent:
+		if s.head+n > s.slots {
+			s.fences[s.region] = gl.FenceSync(gl.GL_SYNC_GPU_COMMANDS_COMPLETE, 0)
+			s.region = (s.region + 1) % streamRegions
+			s.wait(s.region)
+			s.head = 0
+		}
+		first := s.region*s.slots + s.head
+		copy(s.mem[first*s.stride:], (*[1 << 30]byte)(p)[:size:size])
+		s.head += n
+		return first
+	}
+	if s.head+n > s.slots {
+		// orphan the buffer
+		gl.BufferData(gl.GL_ARRAY_BUFFER, s.slots*s.stride, nil, gl.GL_STREAM_DRAW)
+		s.head = 0
+	}
+	first := s.head
+	gl.BufferSubData(gl.GL_ARRAY_BUFFER, first*s.stride, size, p)
+	s.head += n
+	return first
+}
+
+// wait waits until the GPU is done with region r.
+//
+func (s *vertexStream) wait(r int) {
+	f := s.fences[r]
+	if f == (gl.Sync{}) {
+		return
+	}
+	for gl.ClientWaitSync(f, gl.GL_SYNC_FLUSH_COMMANDS_BIT, 1e9) == gl.GL_TIMEOUT_EXPIRED {
+	}
+	gl.DeleteSync(f)
+	s.fences[r] = gl.Sync{}
+}
+
+// release deletes pending fences.
+//
+func (s *vertexStream) release() {
+	for i, f := range s.fences {
+		if f != (gl.Sync{}) {
+			gl.DeleteSync(f)
+			s.fences[i] = gl.Sync{}
+		}
+	}
+	s.mem = nil
+}
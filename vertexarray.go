@@ -0,0 +1,215 @@
+package grog
+
+import (
+	"image/color"
+	"unsafe"
+
+	"github.com/db47h/grog/gl"
+)
+
+// A packedVertex is a vertex as stored in vertex buffers. Positions and
+// texture coordinates are float32, colors are normalized bytes and wrap
+// regions normalized 16 bits integers.
+//
+type packedVertex struct {
+	x, y float32
+	u, v float32
+	c    [4]uint8  // alpha premultiplied color
+	tex  uint8     // texture unit
+	_    [3]uint8
+	w    [4]uint16 // normalized Vertex.Wrap
+}
+
+const (
+	vertexSize = 32 // size of a packedVertex
+	quadSize   = vertexSize * 4
+)
+
+// static check of vertexSize.
+var _ [vertexSize]struct{} = [unsafe.Sizeof(packedVertex{})]struct{}{}
+
+// packColor returns the alpha premultiplied components of c as normalized
+// bytes, or opaque white if c is nil. It is a fast path for the conversion of
+// common color types by gl.ColorModel.
+//
+func packColor(c color.Color) [4]uint8 {
+	switch c := c.(type) {
+	case nil:
+		return [4]uint8{0xff, 0xff, 0xff, 0xff}
+	case gl.Color:
+		return packGLColor(c)
+	case color.RGBA:
+		return [4]uint8{c.R, c.G, c.B, c.A}
+	}
+	r, g, b, a := c.RGBA()
+	return [4]uint8{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8), uint8(a >> 8)}
+}
+
+func packGLColor(c gl.Color) [4]uint8 {
+	return [4]uint8{unorm8(c.R), unorm8(c.G), unorm8(c.B), unorm8(c.A)}
+}
+
+// unorm8 converts v in the range [0, 1] to a normalized byte.
+//
+func unorm8(v float32) uint8 {
+	switch {
+	case v <= 0:
+		return 0
+	case v >= 1:
+		return 0xff
+	}
+	return uint8(v*0xff + .5)
+}
+
+// packWrap returns the components of a Vertex.Wrap region as normalized 16 bits
+// integers.
+//
+func packWrap(w *[4]float32) [4]uint16 {
+	return [4]uint16{unorm16(w[0]), unorm16(w[1]), unorm16(w[2]), unorm16(w[3])}
+}
+
+// unorm16 converts v in the range [0, 1] to a normalized 16 bits integer.
+//
+func unorm16(v float32) uint16 {
+	switch {
+	case v <= 0:
+		return 0
+	case v >= 1:
+		return 0xffff
+	}
+	return uint16(v*0xffff + .5)
+}
+
+// A vertexArray holds the buffers of a batch and their vertex attribute setup.
+//
+// When vertex array objects are available, which core profile contexts
+// require, the attribute setup is kept in a VAO and is only specified again
+// when the material changes. Otherwise, attributes are specified every time the
+// buffers are bound.
+//
+type vertexArray struct {
+	vao    uint32    // 0 if vertex array objects are not available
+	vbo    uint32    // vertices
+	ebo    uint32    // static quad indices
+	ibo    uint32    // index buffer for batches with custom indices
+	attr   *Material // material whose attributes are set up
+	stream vertexStream
+}
+
+// newVertexArray creates the buffers of a batch, with room for n quads. The
+// upload strategy is UploadRing.
+//
+func newVertexArray(f *indexFormat, n int) vertexArray {
+	var va vertexArray
+	if gl.HasVertexArrays() {
+		gl.GenVertexArrays(1, &va.vao)
+		gl.BindVertexArray(va.vao)
+	}
+	gl.GenBuffers(1, &va.ebo)
+	gl.GenBuffers(1, &va.ibo)
+
+	indices := appendQuadIndices(make([]uint32, 0, n*indicesPerQuad), 0, n)
+
+	va.setUpload(UploadRing, n)
+
+	gl.BindBuffer(gl.GL_ELEMENT_ARRAY_BUFFER, va.ebo)
+	f.bufferData(indices, gl.GL_STATIC_DRAW)
+	f.u16 = nil
+
+	if va.vao != 0 {
+		gl.BindVertexArray(0)
+	}
+	return va
+}
+
+// setUpload sets the upload strategy and creates a new vertex buffer with room
+// for quads quads. It returns the strategy in use, which is UploadRing if s is
+// not supported. The new vertex buffer is left bound, but vertex attributes
+// must be set up again.
+//
+func (va *vertexArray) setUpload(s UploadStrategy, quads int) UploadStrategy {
+	if va.vbo != 0 {
+		va.stream.release()
+		gl.DeleteBuffers(1, &va.vbo)
+		va.attr = nil
+	}
+	va.vbo, va.stream = newStreamBuffer(s, quadSize, quads)
+	return va.stream.strategy
+}
+
+// bind binds the buffers and sets up vertex attributes for material m.
+//
+func (va *vertexArray) bind(m *Material) {
+	if va.vao != 0 {
+		gl.BindVertexArray(va.vao)
+	} else {
+		gl.BindBuffer(gl.GL_ELEMENT_ARRAY_BUFFER, va.ebo)
+		va.attr = nil
+	}
+	gl.BindBuffer(gl.GL_ARRAY_BUFFER, va.vbo)
+	va.setMaterial(m)
+}
+
+// setMaterial sets up vertex attributes for material m if needed. The buffers
+// must be bound.
+//
+func (va *vertexArray) setMaterial(m *Material) {
+	if m != va.attr {
+		m.setAttribs()
+		va.attr = m
+	}
+}
+
+// write uploads vertices to the vertex buffer and returns the quad slot of
+// v[0], to be passed to drawQuads or drawIndexed. The buffers must be bound.
+//
+func (va *vertexArray) write(v []packedVertex) int {
+	return va.stream.write(unsafe.Pointer(&v[0]), (len(v)+3)/4, len(v)*vertexSize)
+}
+
+// drawQuads draws n quads from quad slot first with the static quad indices.
+//
+func (va *vertexArray) drawQuads(first, n int, f *indexFormat) {
+	if va.stream.strategy == UploadPersistent {
+		gl.DrawElementsBaseVertexOffset(gl.GL_TRIANGLES, int32(n*indicesPerQuad), f.typ, 0, int32(first*4))
+		return
+	}
+	gl.DrawElementsOffset(gl.GL_TRIANGLES, int32(n*indicesPerQuad), f.typ, first*indicesPerQuad*f.size())
+}
+
+// drawIndexed draws the vertices from quad slot first with custom indices
+// uploaded to ibo, then restores ebo as the current index buffer. Unless base
+// vertices are supported, indices are offset in place. It returns the number of
+// bytes uploaded.
+//
+func (va *vertexArray) drawIndexed(indices []uint32, first int, f *indexFormat) int {
+	gl.BindBuffer(gl.GL_ELEMENT_ARRAY_BUFFER, va.ibo)
+	var n int
+	if va.stream.strategy == UploadPersistent {
+		n = f.bufferData(indices, gl.GL_STREAM_DRAW)
+		gl.DrawElementsBaseVertexOffset(gl.GL_TRIANGLES, int32(len(indices)), f.typ, 0, int32(first*4))
+	} else {
+		if base := uint32(first * 4); base != 0 {
+			for i := range indices {
+				indices[i] += base
+			}
+		}
+		n = f.bufferData(indices, gl.GL_STREAM_DRAW)
+		gl.DrawElements(gl.GL_TRIANGLES, int32(len(indices)), f.typ, nil)
+	}
+	gl.BindBuffer(gl.GL_ELEMENT_ARRAY_BUFFER, va.ebo)
+	return n
+}
+
+// delete deletes the buffers and vertex array object.
+//
+func (va *vertexArray) delete() {
+	va.stream.release()
+	gl.DeleteBuffers(1, &va.ibo)
+	gl.DeleteBuffers(1, &va.ebo)
+	gl.DeleteBuffers(1, &va.vbo)
+	if va.vao != 0 {
+		gl.DeleteVertexArrays(1, &va.vao)
+	}
+	va.attr = nil
+}